@@ -0,0 +1,422 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"kiosk/internal/config"
+)
+
+const defaultActionTimeout = 30 * time.Second
+
+// actionTarget receives the input of an action sequence, either a window
+// through xdotool or a browser tab through CDP.
+type actionTarget interface {
+	key(combo string) error
+	typeText(text string) error
+	click(x, y, button int) error
+	title() (string, error)
+}
+
+// runActions runs an action sequence in order, stopping at the first action
+// that fails.
+func (kiosk *Kiosk) runActions(target actionTarget, actions *config.ActionsConfig, sequence []config.Action) error {
+	timeout := defaultActionTimeout
+	if actions.Timeout > 0 {
+		timeout = time.Duration(actions.Timeout) * time.Second
+	}
+
+	for i, action := range sequence {
+		var err error
+
+		switch {
+		case action.Key != "":
+			err = target.key(action.Key)
+		case action.Type != "":
+			err = target.typeText(action.Type)
+		case action.Click != nil:
+			button := action.Click.Button
+			if button == 0 {
+				button = 1
+			}
+			err = target.click(action.Click.X, action.Click.Y, button)
+		case action.WaitForTitle != "":
+			err = kiosk.waitForTitle(target, action.WaitForTitle, timeout)
+		case action.Wait > 0:
+			select {
+			case <-time.After(time.Duration(action.Wait) * time.Millisecond):
+			case <-kiosk.ctx.Done():
+				err = kiosk.ctx.Err()
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("action %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (kiosk *Kiosk) waitForTitle(target actionTarget, pattern string, timeout time.Duration) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid title pattern %q: %w", pattern, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		if title, err := target.title(); err == nil && re.MatchString(title) {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %v waiting for title %s", timeout, pattern)
+		}
+
+		select {
+		case <-time.After(250 * time.Millisecond):
+		case <-kiosk.ctx.Done():
+			return kiosk.ctx.Err()
+		}
+	}
+}
+
+// windowTarget sends actions to a display's window with xdotool.
+type windowTarget struct {
+	kiosk    *Kiosk
+	name     string
+	windowID string
+}
+
+func (t windowTarget) xdotool(args ...string) error {
+	out, err := t.kiosk.displayCommand(t.kiosk.ctx, t.name, "xdotool", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("xdotool %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (t windowTarget) key(combo string) error {
+	if err := t.xdotool("windowactivate", t.windowID); err != nil {
+		return err
+	}
+	return t.xdotool("key", "--window", t.windowID, combo)
+}
+
+func (t windowTarget) typeText(text string) error {
+	if err := t.xdotool("windowactivate", t.windowID); err != nil {
+		return err
+	}
+	return t.xdotool("type", "--window", t.windowID, text)
+}
+
+func (t windowTarget) click(x, y, button int) error {
+	return t.xdotool("mousemove", "--window", t.windowID, strconv.Itoa(x), strconv.Itoa(y), "click", strconv.Itoa(button))
+}
+
+func (t windowTarget) title() (string, error) {
+	out, err := t.kiosk.displayCommand(t.kiosk.ctx, t.name, "xdotool", "getwindowname", t.windowID).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// tabTarget sends actions to a browser tab with CDP input events.
+type tabTarget struct {
+	session *CDPSession
+}
+
+// cdpKey describes a key for Input.dispatchKeyEvent.
+type cdpKey struct {
+	key     string
+	code    string
+	keyCode int
+	text    string
+}
+
+// namedKeys maps xdotool key names to DOM keys.
+var namedKeys = map[string]cdpKey{
+	"return":    {key: "Enter", code: "Enter", keyCode: 13, text: "\r"},
+	"enter":     {key: "Enter", code: "Enter", keyCode: 13, text: "\r"},
+	"tab":       {key: "Tab", code: "Tab", keyCode: 9},
+	"escape":    {key: "Escape", code: "Escape", keyCode: 27},
+	"backspace": {key: "Backspace", code: "Backspace", keyCode: 8},
+	"delete":    {key: "Delete", code: "Delete", keyCode: 46},
+	"space":     {key: " ", code: "Space", keyCode: 32, text: " "},
+	"up":        {key: "ArrowUp", code: "ArrowUp", keyCode: 38},
+	"down":      {key: "ArrowDown", code: "ArrowDown", keyCode: 40},
+	"left":      {key: "ArrowLeft", code: "ArrowLeft", keyCode: 37},
+	"right":     {key: "ArrowRight", code: "ArrowRight", keyCode: 39},
+	"home":      {key: "Home", code: "Home", keyCode: 36},
+	"end":       {key: "End", code: "End", keyCode: 35},
+	"page_up":   {key: "PageUp", code: "PageUp", keyCode: 33},
+	"prior":     {key: "PageUp", code: "PageUp", keyCode: 33},
+	"page_down": {key: "PageDown", code: "PageDown", keyCode: 34},
+	"next":      {key: "PageDown", code: "PageDown", keyCode: 34},
+}
+
+// CDP modifier bits
+var keyModifiers = map[string]int{
+	"alt":     1,
+	"ctrl":    2,
+	"control": 2,
+	"super":   4,
+	"meta":    4,
+	"shift":   8,
+}
+
+// parseKey translates a key combination in xdotool notation, e.g. ctrl+r,
+// into a DOM key and CDP modifiers.
+func parseKey(combo string) (cdpKey, int, error) {
+	parts := strings.Split(combo, "+")
+	modifiers := 0
+
+	for _, part := range parts[:len(parts)-1] {
+		bit, ok := keyModifiers[strings.ToLower(part)]
+		if !ok {
+			return cdpKey{}, 0, fmt.Errorf("unknown modifier %q in %s", part, combo)
+		}
+		modifiers |= bit
+	}
+
+	name := parts[len(parts)-1]
+	if k, ok := namedKeys[strings.ToLower(name)]; ok {
+		return k, modifiers, nil
+	}
+
+	if len(name) >= 2 && (name[0] == 'F' || name[0] == 'f') {
+		if n, err := strconv.Atoi(name[1:]); err == nil && n >= 1 && n <= 12 {
+			return cdpKey{key: "F" + name[1:], code: "F" + name[1:], keyCode: 111 + n}, modifiers, nil
+		}
+	}
+
+	runes := []rune(name)
+	if len(runes) != 1 {
+		return cdpKey{}, 0, fmt.Errorf("unknown key %q in %s", name, combo)
+	}
+
+	r := runes[0]
+	k := cdpKey{key: name, text: name}
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		upper := strings.ToUpper(name)
+		k.code = "Key" + upper
+		k.keyCode = int(upper[0])
+		if modifiers&8 != 0 || (r >= 'A' && r <= 'Z') {
+			k.key, k.text = upper, upper
+		}
+	case r >= '0' && r <= '9':
+		k.code = "Digit" + name
+		k.keyCode = int(r)
+	}
+
+	return k, modifiers, nil
+}
+
+func (t tabTarget) key(combo string) error {
+	k, modifiers, err := parseKey(combo)
+	if err != nil {
+		return err
+	}
+
+	down := map[string]interface{}{
+		"type":                  "rawKeyDown",
+		"key":                   k.key,
+		"code":                  k.code,
+		"windowsVirtualKeyCode": k.keyCode,
+		"modifiers":             modifiers,
+	}
+
+	// Keys producing text type it unless used as a shortcut
+	if k.text != "" && modifiers&^8 == 0 {
+		down["type"] = "keyDown"
+		down["text"] = k.text
+	}
+
+	if err := t.session.Call("Input.dispatchKeyEvent", down, nil); err != nil {
+		return err
+	}
+
+	return t.session.Call("Input.dispatchKeyEvent", map[string]interface{}{
+		"type":                  "keyUp",
+		"key":                   k.key,
+		"code":                  k.code,
+		"windowsVirtualKeyCode": k.keyCode,
+		"modifiers":             modifiers,
+	}, nil)
+}
+
+func (t tabTarget) typeText(text string) error {
+	return t.session.Call("Input.insertText", map[string]interface{}{"text": text}, nil)
+}
+
+var mouseButtons = map[int]string{1: "left", 2: "middle", 3: "right"}
+
+func (t tabTarget) click(x, y, button int) error {
+	name, ok := mouseButtons[button]
+	if !ok {
+		return fmt.Errorf("unknown mouse button %d", button)
+	}
+
+	events := []map[string]interface{}{
+		{"type": "mouseMoved", "x": x, "y": y},
+		{"type": "mousePressed", "x": x, "y": y, "button": name, "clickCount": 1},
+		{"type": "mouseReleased", "x": x, "y": y, "button": name, "clickCount": 1},
+	}
+
+	for _, event := range events {
+		if err := t.session.Call("Input.dispatchMouseEvent", event, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t tabTarget) title() (string, error) {
+	var title string
+	err := t.session.Evaluate("document.title", &title)
+	return title, err
+}
+
+// runWindowActions runs one of an exec display's action sequences against its
+// window.
+func (kiosk *Kiosk) runWindowActions(name, kind string, sequence []config.Action) {
+	kiosk.mu.Lock()
+	window, ok := kiosk.windows[name]
+	var windowID string
+	if ok {
+		windowID = window.WindowID
+	}
+	kiosk.mu.Unlock()
+
+	if len(sequence) == 0 || windowID == "" {
+		return
+	}
+
+	log.Printf("[%s] Running %s actions on window %s", name, kind, windowID)
+
+	target := windowTarget{kiosk: kiosk, name: name, windowID: windowID}
+	if err := kiosk.runActions(target, window.Config.Exec.Actions, sequence); err != nil {
+		log.Printf("[%s] Error running %s actions on window %s: %v", name, kind, windowID, err)
+	}
+}
+
+// runTabActions runs one of a tab's action sequences against its page.
+func (kiosk *Kiosk) runTabActions(name string, tab *TabState, kind string, sequence []config.Action) {
+	if len(sequence) == 0 {
+		return
+	}
+
+	session, err := kiosk.tabSession(name, tab)
+	if err != nil {
+		log.Printf("[%s] Error running %s actions on tab %s: %v", name, kind, tab.URL, err)
+		return
+	}
+
+	log.Printf("[%s] Running %s actions on tab %s", name, kind, tab.URL)
+
+	kiosk.mu.Lock()
+	display, ok := kiosk.windows[name]
+	if ok {
+		display.runningActions++
+	}
+	kiosk.mu.Unlock()
+
+	if err := kiosk.runActions(tabTarget{session: session}, tab.Actions, sequence); err != nil {
+		log.Printf("[%s] Error running %s actions on tab %s: %v", name, kind, tab.URL, err)
+	}
+
+	// The page reports the dispatched input a little later, which must not
+	// count as a visitor using the display
+	if ok {
+		kiosk.mu.Lock()
+		display.runningActions--
+		display.ignoreInputUntil = time.Now().Add(time.Second)
+		kiosk.mu.Unlock()
+	}
+}
+
+// activateActions runs a tab's actions after it has been activated: its launch
+// actions the first time it is shown, then its activate actions.
+func (kiosk *Kiosk) activateActions(name string, tab *TabState) {
+	if tab.Actions == nil {
+		return
+	}
+
+	if !tab.launchActionsDone {
+		tab.launchActionsDone = true
+		kiosk.runTabActions(name, tab, "launch", tab.Actions.Launch)
+	}
+
+	kiosk.runTabActions(name, tab, "activate", tab.Actions.Activate)
+}
+
+// actionTimers runs the timer actions of the display's window or tabs every
+// interval. Tabs only get them while they are the active tab.
+func (kiosk *Kiosk) actionTimers(name string) {
+	kiosk.mu.Lock()
+	display, ok := kiosk.windows[name]
+	kiosk.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	timer := func(actions *config.ActionsConfig, run func()) {
+		if actions == nil || actions.Interval <= 0 || len(actions.Timer) == 0 {
+			return
+		}
+
+		interval := time.Duration(actions.Interval) * time.Second
+
+		kiosk.wg.Add(1)
+		go func() {
+			defer kiosk.wg.Done()
+
+			for {
+				select {
+				case <-time.After(interval):
+				case <-kiosk.ctx.Done():
+					return
+				}
+
+				run()
+			}
+		}()
+	}
+
+	if display.Config.Exec.Command != "" {
+		timer(display.Config.Exec.Actions, func() {
+			kiosk.mu.Lock()
+			running := display.Exec.Running
+			kiosk.mu.Unlock()
+
+			if running {
+				kiosk.runWindowActions(name, "timer", display.Config.Exec.Actions.Timer)
+			}
+		})
+		return
+	}
+
+	for i, tab := range display.Tabs {
+		i, tab := i, tab
+		timer(tab.Actions, func() {
+			// Leave visitors of interactive displays undisturbed
+			kiosk.mu.Lock()
+			active := display.ActiveTab == i && !display.Interacting
+			kiosk.mu.Unlock()
+
+			if active {
+				kiosk.runTabActions(name, tab, "timer", tab.Actions.Timer)
+			}
+		})
+	}
+}
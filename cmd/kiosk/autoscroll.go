@@ -0,0 +1,109 @@
+package main
+
+import (
+	"log"
+	"math"
+	"time"
+)
+
+const defaultAutoScrollSpeed = 50
+
+// autoScrollScript scrolls the page with requestAnimationFrame, so it only runs
+// while the tab is visible. Starting it again stops the previous run.
+const autoScrollScript = `(function (speed, pauseTop, pauseBottom, loop) {
+  if (window.__kioskAutoScroll) window.__kioskAutoScroll.stop();
+  var stopped = false;
+  window.__kioskAutoScroll = { stop: function () { stopped = true; } };
+
+  var pos = 0, last = null, phase = "top", phaseEnd = null;
+  window.scrollTo(0, 0);
+
+  function step(t) {
+    if (stopped) return;
+    if (phaseEnd === null) phaseEnd = t + pauseTop * 1000;
+    var max = Math.max(0, document.documentElement.scrollHeight - window.innerHeight);
+
+    if (phase === "scroll") {
+      pos = Math.min(max, pos + (speed * (t - last)) / 1000);
+      window.scrollTo(0, pos);
+      if (pos >= max) {
+        phase = "bottom";
+        phaseEnd = t + pauseBottom * 1000;
+      }
+    } else if (t >= phaseEnd) {
+      if (phase === "top") {
+        phase = "scroll";
+      } else {
+        if (!loop) return;
+        pos = 0;
+        window.scrollTo(0, 0);
+        phase = "top";
+        phaseEnd = t + pauseTop * 1000;
+      }
+    }
+
+    last = t;
+    requestAnimationFrame(step);
+  }
+
+  requestAnimationFrame(step);
+})`
+
+const stopAutoScrollScript = `(function () {
+  if (window.__kioskAutoScroll) window.__kioskAutoScroll.stop();
+})()`
+
+const scrollHeightScript = `Math.max(0, document.documentElement.scrollHeight - window.innerHeight)`
+
+// startAutoScroll starts scrolling the active tab and returns how long it
+// should be shown, which is computed from the page height if configured.
+func (kiosk *Kiosk) startAutoScroll(name string, tab *TabState, dwell time.Duration) time.Duration {
+	scroll := tab.AutoScroll
+	if scroll == nil {
+		return dwell
+	}
+
+	session, err := kiosk.tabSession(name, tab)
+	if err != nil {
+		log.Printf("[%s] Error auto-scrolling tab %s: %v", name, tab.URL, err)
+		return dwell
+	}
+
+	speed := scroll.Speed
+	if speed <= 0 {
+		speed = defaultAutoScrollSpeed
+	}
+
+	if scroll.DwellFromHeight {
+		var height float64
+		if err := session.Evaluate(scrollHeightScript, &height); err != nil {
+			log.Printf("[%s] Error measuring tab %s: %v", name, tab.URL, err)
+		} else {
+			seconds := float64(scroll.PauseTop+scroll.PauseBottom) + height/float64(speed)
+			dwell = time.Duration(math.Ceil(seconds)) * time.Second
+			log.Printf("[%s] Tab %s is %.0fpx longer than the window, showing it for %v", name, tab.URL, height, dwell)
+		}
+	}
+
+	err = evaluateWithArgs(session, autoScrollScript, speed, scroll.PauseTop, scroll.PauseBottom, scroll.Loop)
+	if err != nil {
+		log.Printf("[%s] Error auto-scrolling tab %s: %v", name, tab.URL, err)
+	}
+
+	return dwell
+}
+
+func (kiosk *Kiosk) stopAutoScroll(name string, tab *TabState) {
+	if tab.AutoScroll == nil {
+		return
+	}
+
+	session, err := kiosk.tabSession(name, tab)
+	if err != nil {
+		return
+	}
+
+	if err := session.Evaluate(stopAutoScrollScript, nil); err != nil {
+		log.Printf("[%s] Error stopping auto-scroll of tab %s: %v", name, tab.URL, err)
+	}
+}
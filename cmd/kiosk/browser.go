@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+type BrowserFlavour struct {
+	Binary       string
+	WindowSearch string
+}
+
+// knownBrowsers lists the Chromium-family browsers that are tried, in order,
+// when no browser binary is configured.
+var knownBrowsers = []BrowserFlavour{
+	{Binary: "chromium", WindowSearch: "chromium"},
+	{Binary: "chromium-browser", WindowSearch: "chromium"},
+	{Binary: "/snap/bin/chromium", WindowSearch: "chromium"},
+	{Binary: "google-chrome", WindowSearch: "chrome"},
+	{Binary: "google-chrome-stable", WindowSearch: "chrome"},
+	{Binary: "brave-browser", WindowSearch: "brave"},
+	{Binary: "brave", WindowSearch: "brave"},
+	{Binary: "microsoft-edge", WindowSearch: "edge"},
+}
+
+// browserWindowSearch guesses the window name search for a browser binary.
+func browserWindowSearch(binary string) string {
+	base := filepath.Base(binary)
+	for _, b := range knownBrowsers {
+		if filepath.Base(b.Binary) == base {
+			return b.WindowSearch
+		}
+	}
+
+	return "chromium"
+}
+
+// browser resolves the browser binary to launch and the window search used to
+// find its windows.
+func (kiosk *Kiosk) browser() (BrowserFlavour, error) {
+	cfg := kiosk.cfg.Browser
+
+	if cfg.Binary != "" {
+		path, err := exec.LookPath(cfg.Binary)
+		if err != nil {
+			return BrowserFlavour{}, fmt.Errorf("browser %s not found: %w", cfg.Binary, err)
+		}
+
+		search := cfg.WindowSearch
+		if search == "" {
+			search = browserWindowSearch(cfg.Binary)
+		}
+
+		return BrowserFlavour{Binary: path, WindowSearch: search}, nil
+	}
+
+	for _, b := range knownBrowsers {
+		path, err := exec.LookPath(b.Binary)
+		if err != nil {
+			continue
+		}
+
+		search := cfg.WindowSearch
+		if search == "" {
+			search = b.WindowSearch
+		}
+
+		return BrowserFlavour{Binary: path, WindowSearch: search}, nil
+	}
+
+	return BrowserFlavour{}, fmt.Errorf("no Chromium-family browser found, set browser.binary")
+}
+
+func flagName(flag string) string {
+	name, _, _ := strings.Cut(strings.TrimLeft(flag, "-"), "=")
+	return name
+}
+
+// browserArgs applies the configured flag removals and additions to the
+// default browser flags. Trailing non-flag arguments (URLs) are kept last.
+func (kiosk *Kiosk) browserArgs(flags []string, urls ...string) []string {
+	remove := make(map[string]bool)
+	for _, f := range kiosk.cfg.Browser.RemoveFlags {
+		remove[flagName(f)] = true
+	}
+
+	args := make([]string, 0, len(flags)+len(kiosk.cfg.Browser.ExtraFlags)+len(urls))
+	for _, f := range flags {
+		if remove[flagName(f)] {
+			continue
+		}
+		args = append(args, f)
+	}
+
+	args = append(args, kiosk.cfg.Browser.ExtraFlags...)
+	return append(args, urls...)
+}
+
+func (kiosk *Kiosk) browserUserDataDir(name string) string {
+	base := kiosk.cfg.Browser.UserDataDir
+	if base == "" {
+		base = "/tmp"
+	}
+
+	return filepath.Join(base, fmt.Sprintf(".kiosk-chrome-user-data-%s", name))
+}
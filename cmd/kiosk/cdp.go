@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// cdpReadLimit is the largest DevTools message accepted. Evaluation results
+// and network events easily exceed the websocket default of 32KiB.
+const cdpReadLimit = 16 << 20
+
+const cdpCallTimeout = 10 * time.Second
+
+type cdpMessage struct {
+	ID     int             `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *cdpError       `json:"error,omitempty"`
+}
+
+type cdpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *cdpError) Error() string {
+	return fmt.Sprintf("cdp error %d: %s", e.Code, e.Message)
+}
+
+// CDPSession is a persistent DevTools protocol connection to a single tab.
+// Commands are matched to their responses by ID and events are dispatched to
+// the handlers registered with On.
+type CDPSession struct {
+	conn      *websocket.Conn
+	ctx       context.Context
+	cancel    context.CancelFunc
+	requestID *RequestID
+
+	mu        sync.Mutex
+	pending   map[int]chan cdpMessage
+	handlers  map[string]map[int]func(json.RawMessage)
+	handlerID int
+}
+
+func dialCDP(ctx context.Context, wsURL string, requestID *RequestID) (*CDPSession, error) {
+	if wsURL == "" {
+		return nil, errors.New("no websocket url")
+	}
+
+	dialCtx, dialCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer dialCancel()
+
+	c, _, err := websocket.Dial(dialCtx, wsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.SetReadLimit(cdpReadLimit)
+
+	ctx, cancel := context.WithCancel(ctx)
+	session := &CDPSession{
+		conn:      c,
+		ctx:       ctx,
+		cancel:    cancel,
+		requestID: requestID,
+		pending:   make(map[int]chan cdpMessage),
+		handlers:  make(map[string]map[int]func(json.RawMessage)),
+	}
+
+	go session.readLoop()
+
+	return session, nil
+}
+
+func (s *CDPSession) readLoop() {
+	defer s.Close()
+
+	for {
+		var msg cdpMessage
+		if err := wsjson.Read(s.ctx, s.conn, &msg); err != nil {
+			return
+		}
+
+		if msg.ID != 0 {
+			s.mu.Lock()
+			ch, ok := s.pending[msg.ID]
+			delete(s.pending, msg.ID)
+			s.mu.Unlock()
+
+			if ok {
+				ch <- msg
+			}
+			continue
+		}
+
+		if msg.Method == "" {
+			continue
+		}
+
+		s.mu.Lock()
+		handlers := make([]func(json.RawMessage), 0, len(s.handlers[msg.Method]))
+		for _, fn := range s.handlers[msg.Method] {
+			handlers = append(handlers, fn)
+		}
+		s.mu.Unlock()
+
+		for _, fn := range handlers {
+			fn(msg.Params)
+		}
+	}
+}
+
+// Done is closed once the session's connection is gone.
+func (s *CDPSession) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+func (s *CDPSession) Close() {
+	s.cancel()
+	s.conn.Close(websocket.StatusNormalClosure, "bye")
+}
+
+// On registers a handler for a DevTools event and returns a function removing
+// it again. Handlers run on the read loop and must not block on further calls
+// to the same session.
+func (s *CDPSession) On(method string, fn func(params json.RawMessage)) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.handlers[method] == nil {
+		s.handlers[method] = make(map[int]func(json.RawMessage))
+	}
+
+	s.handlerID++
+	id := s.handlerID
+	s.handlers[method][id] = fn
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.handlers[method], id)
+	}
+}
+
+// Call sends a command and waits for its response. If result is non-nil the
+// response result is decoded into it.
+func (s *CDPSession) Call(method string, params interface{}, result interface{}) error {
+	id := s.requestID.Next()
+	ch := make(chan cdpMessage, 1)
+
+	s.mu.Lock()
+	s.pending[id] = ch
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(s.ctx, cdpCallTimeout)
+	defer cancel()
+
+	req := map[string]interface{}{
+		"id":     id,
+		"method": method,
+	}
+	if params != nil {
+		req["params"] = params
+	}
+
+	if err := wsjson.Write(ctx, s.conn, req); err != nil {
+		return err
+	}
+
+	select {
+	case msg := <-ch:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil && len(msg.Result) > 0 {
+			return json.Unmarshal(msg.Result, result)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	}
+}
+
+type cdpEvaluateResult struct {
+	Result struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	} `json:"result"`
+	ExceptionDetails *struct {
+		Text string `json:"text"`
+	} `json:"exceptionDetails"`
+}
+
+// Evaluate runs a JavaScript expression in the page, waiting for it if it
+// returns a promise. If result is non-nil the returned value is decoded into
+// it.
+func (s *CDPSession) Evaluate(expression string, result interface{}) error {
+	var res cdpEvaluateResult
+	err := s.Call("Runtime.evaluate", map[string]interface{}{
+		"expression":    expression,
+		"awaitPromise":  true,
+		"returnByValue": true,
+	}, &res)
+	if err != nil {
+		return err
+	}
+
+	if res.ExceptionDetails != nil {
+		return fmt.Errorf("evaluation failed: %s", res.ExceptionDetails.Text)
+	}
+
+	if result != nil && len(res.Result.Value) > 0 {
+		return json.Unmarshal(res.Result.Value, result)
+	}
+	return nil
+}
+
+// tabSession returns the open DevTools session of a tab, connecting and
+// setting it up first if needed.
+func (kiosk *Kiosk) tabSession(name string, tab *TabState) (*CDPSession, error) {
+	tab.sessionMu.Lock()
+	defer tab.sessionMu.Unlock()
+
+	kiosk.mu.Lock()
+	session := tab.Session
+	kiosk.mu.Unlock()
+
+	if session != nil {
+		select {
+		case <-session.Done():
+		default:
+			return session, nil
+		}
+	}
+
+	session, err := dialCDP(kiosk.ctx, tab.WSURL, kiosk.requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := kiosk.setupTabSession(name, tab, session); err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	kiosk.mu.Lock()
+	tab.Session = session
+	kiosk.mu.Unlock()
+
+	log.Printf("[%s] Connected to tab %s", name, tab.URL)
+	return session, nil
+}
+
+// setupTabSession enables the domains and registers the per-session state a
+// tab needs. It runs again whenever the session is reconnected.
+func (kiosk *Kiosk) setupTabSession(name string, tab *TabState, session *CDPSession) error {
+	if err := session.Call("Page.enable", nil, nil); err != nil {
+		return err
+	}
+
+	if err := session.Call("Page.setLifecycleEventsEnabled", map[string]interface{}{"enabled": true}, nil); err != nil {
+		return err
+	}
+
+	if err := kiosk.registerHealth(name, tab, session); err != nil {
+		return err
+	}
+
+	// Network settings failing (e.g. a missing secret) must not keep the tab
+	// from loading
+	if err := kiosk.registerNetwork(name, tab, session); err != nil {
+		log.Printf("[%s] Error applying network settings to tab %s: %v", name, tab.URL, err)
+	}
+
+	if err := kiosk.registerInjections(name, tab, session); err != nil {
+		log.Printf("[%s] Error injecting into tab %s: %v", name, tab.URL, err)
+	}
+
+	if err := kiosk.registerOverlay(name, tab, session); err != nil {
+		log.Printf("[%s] Error adding overlay to tab %s: %v", name, tab.URL, err)
+	}
+
+	if err := kiosk.registerView(name, tab, session); err != nil {
+		return err
+	}
+
+	if err := kiosk.registerInteractive(name, tab, session); err != nil {
+		return err
+	}
+
+	if err := kiosk.registerNavigationLock(name, tab, session); err != nil {
+		log.Printf("[%s] Error locking navigation of tab %s: %v", name, tab.URL, err)
+	}
+
+	kiosk.registerLogin(name, tab, session)
+	kiosk.registerHealthCheck(name, tab, session)
+	return nil
+}
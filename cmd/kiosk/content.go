@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// pageURL is the address loaded for a tab. Local content tabs are rendered by
+// the kiosk web server, which looks them up by display and configured URL.
+func (kiosk *Kiosk) pageURL(name string, tab *TabState) string {
+	if !tab.IsLocalContent() {
+		return tab.URL
+	}
+
+	v := url.Values{}
+	v.Set("display", name)
+	v.Set("url", tab.URL)
+	return fmt.Sprintf("http://localhost:%d/content?%s", kiosk.webPort, v.Encode())
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"kiosk/internal/config"
+)
+
+const (
+	defaultRestartDelay    = time.Second
+	defaultMaxRestartDelay = 60 * time.Second
+
+	// A process running at least this long resets the restart backoff
+	stableRunTime = time.Minute
+)
+
+// ExecState is the supervision state of an exec display's process.
+type ExecState struct {
+	PID      int
+	Running  bool
+	Started  time.Time
+	Restarts int
+	ExitCode int
+	LastExit time.Time
+	LastErr  string
+
+	exited chan error
+}
+
+// How long processes left in an exec command's group get to exit after
+// SIGTERM before they are killed, shorter than the shutdown timeout so they
+// are gone before the kiosk exits
+const processGroupGrace = 1500 * time.Millisecond
+
+// How long Wait keeps copying output after the process exited, as children
+// that inherited its stdout may hold the pipe open indefinitely
+const outputWaitDelay = 2 * time.Second
+
+// outputLogger is an io.Writer that writes a process output stream into the
+// log line by line.
+type outputLogger struct {
+	name   string
+	stream string
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (l *outputLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.buf = append(l.buf, p...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		log.Printf("[%s] %s: %s", l.name, l.stream, bytes.TrimRight(l.buf[:i], "\r"))
+		l.buf = l.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// flush logs a final line that wasn't terminated by a newline.
+func (l *outputLogger) flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.buf) > 0 {
+		log.Printf("[%s] %s: %s", l.name, l.stream, l.buf)
+		l.buf = nil
+	}
+}
+
+// execUser looks up the user an exec display runs as by name or uid.
+func execUser(name string) (*user.User, *syscall.Credential, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		var lookupErr error
+		u, lookupErr = user.LookupId(name)
+		if lookupErr != nil {
+			return nil, nil, fmt.Errorf("unknown user %s: %w", name, err)
+		}
+	}
+
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid uid of user %s: %w", name, err)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid gid of user %s: %w", name, err)
+	}
+
+	credential := &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+
+	groupIDs, err := u.GroupIds()
+	if err == nil {
+		for _, id := range groupIDs {
+			if g, err := strconv.ParseUint(id, 10, 32); err == nil {
+				credential.Groups = append(credential.Groups, uint32(g))
+			}
+		}
+	}
+
+	return u, credential, nil
+}
+
+// execCommand builds the command of an exec display with its shell mode,
+// working directory, environment and user applied.
+func (kiosk *Kiosk) execCommand(name string, window *DisplayState) (*exec.Cmd, error) {
+	execCfg := window.Config.Exec
+
+	bin, args := execCfg.Command, execCfg.Args
+	if execCfg.Shell {
+		// The args become the script's positional parameters $1, $2, ...
+		bin, args = "/bin/sh", append([]string{"-c", execCfg.Command, "sh"}, execCfg.Args...)
+	}
+
+	cmd := kiosk.displayCommand(kiosk.ctx, name, bin, args...)
+	cmd.Dir = execCfg.WorkingDir
+
+	// The command gets a process group of its own, so whatever it spawns
+	// (e.g. the program a shell script starts) is stopped along with it
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		killProcessGroup(cmd.Process.Pid)
+		return nil
+	}
+
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+
+	if execCfg.User != "" {
+		u, credential, err := execUser(execCfg.User)
+		if err != nil {
+			return nil, err
+		}
+
+		cmd.SysProcAttr.Credential = credential
+
+		// These point at the kiosk user's files, which the user can't read
+		env = withoutEnv(env, "XAUTHORITY", "XDG_RUNTIME_DIR", "DBUS_SESSION_BUS_ADDRESS")
+		env = append(env, "HOME="+u.HomeDir, "USER="+u.Username, "LOGNAME="+u.Username)
+	}
+
+	// Later entries win, so the configured variables override inherited ones
+	for _, v := range execCfg.Env {
+		if !strings.Contains(v, "=") {
+			log.Printf("[%s] Ignoring environment variable %q without a value", name, v)
+			continue
+		}
+		env = append(env, v)
+	}
+	cmd.Env = env
+
+	return cmd, nil
+}
+
+// withoutEnv drops variables from an environment.
+func withoutEnv(env []string, names ...string) []string {
+	result := make([]string, 0, len(env))
+	for _, v := range env {
+		key, _, _ := strings.Cut(v, "=")
+
+		drop := false
+		for _, name := range names {
+			if key == name {
+				drop = true
+				break
+			}
+		}
+
+		if !drop {
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
+
+// killProcessGroup terminates an exec command's process group, killing it if
+// it hasn't exited after the grace period.
+func killProcessGroup(pid int) {
+	if err := syscall.Kill(-pid, syscall.SIGTERM); err != nil {
+		return
+	}
+
+	deadline := time.Now().Add(processGroupGrace)
+	for time.Now().Before(deadline) {
+		// Signal 0 only checks whether anything in the group is left
+		if err := syscall.Kill(-pid, 0); err != nil {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	syscall.Kill(-pid, syscall.SIGKILL)
+}
+
+// startExec starts a display's command with its output captured into the log
+// and reports its exit on the returned channel.
+func (kiosk *Kiosk) startExec(name string, window *DisplayState) (chan error, error) {
+	cmd, err := kiosk.execCommand(name, window)
+	if err != nil {
+		return nil, err
+	}
+
+	stdout := &outputLogger{name: name, stream: "stdout"}
+	stderr := &outputLogger{name: name, stream: "stderr"}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = outputWaitDelay
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+
+		// Output left unread once the process is gone doesn't make the exit
+		// a failure
+		if errors.Is(err, exec.ErrWaitDelay) {
+			err = nil
+		}
+
+		stdout.flush()
+		stderr.flush()
+		exited <- err
+	}()
+
+	kiosk.mu.Lock()
+	window.Exec.PID = cmd.Process.Pid
+	window.Exec.Running = true
+	window.Exec.exited = exited
+	kiosk.mu.Unlock()
+
+	return exited, nil
+}
+
+func (kiosk *Kiosk) launchCustom(name string) {
+	kiosk.mu.Lock()
+	window, ok := kiosk.windows[name]
+	kiosk.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	kiosk.mu.Lock()
+	window.Exec.Started = time.Now()
+	window.Exec.LastErr = ""
+	kiosk.mu.Unlock()
+
+	originalWinIDs, err := kiosk.xdotoolSearchVisible(name, window.Config.Exec.WindowSearch)
+	if err != nil {
+		log.Printf("[%s] Error searching for visible windows: %v", name, err)
+
+		kiosk.mu.Lock()
+		window.Exec.LastErr = err.Error()
+		kiosk.mu.Unlock()
+		return
+	}
+
+	log.Printf("[%s] Launching custom command: %s with args: %v", name, window.Config.Exec.Command, window.Config.Exec.Args)
+
+	exited, err := kiosk.startExec(name, window)
+	if err != nil {
+		log.Printf("[%s] Error starting command: %v", name, err)
+
+		kiosk.mu.Lock()
+		window.Exec.LastErr = err.Error()
+		kiosk.mu.Unlock()
+		return
+	}
+
+	firstRun := true
+	for {
+		if !firstRun {
+			select {
+			case <-time.After(time.Second):
+			case <-kiosk.ctx.Done():
+				return
+			}
+		}
+		firstRun = false
+
+		// Stop looking once the process is gone, execCycle handles its exit
+		if len(exited) > 0 {
+			log.Printf("[%s] Command exited before its window was found", name)
+			return
+		}
+
+		winIDs, err := kiosk.xdotoolSearchVisible(name, window.Config.Exec.WindowSearch)
+		if err != nil {
+			log.Printf("[%s] Error searching for visible windows: %v", name, err)
+			continue
+		}
+
+		if len(winIDs) == 0 {
+			log.Printf("[%s] No visible windows found for %s", name, window.Config.Exec.WindowSearch)
+			continue
+		}
+
+		winID, err := kiosk.xdotoolFindLatestWindowID(name, originalWinIDs, winIDs)
+		if err != nil {
+			log.Printf("[%s] Error finding latest window ID: %v", name, err)
+			continue
+		}
+
+		kiosk.mu.Lock()
+		window.WindowID = winID
+		kiosk.mu.Unlock()
+		break
+	}
+}
+
+// restartDelay returns the backoff before the next restart after a number of
+// consecutive quick exits.
+func restartDelay(execCfg config.ExecConfig, quickExits int) time.Duration {
+	delay := defaultRestartDelay
+	if execCfg.RestartDelay > 0 {
+		delay = time.Duration(execCfg.RestartDelay) * time.Second
+	}
+
+	maxDelay := defaultMaxRestartDelay
+	if execCfg.MaxRestartDelay > 0 {
+		maxDelay = time.Duration(execCfg.MaxRestartDelay) * time.Second
+	}
+
+	for i := 0; i < quickExits && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	return delay
+}
+
+// shouldRestart applies the display's restart policy to an exit.
+func shouldRestart(policy string, exitCode int) bool {
+	switch policy {
+	case config.RestartAlways:
+		return true
+	case config.RestartOnFailure:
+		return exitCode != 0
+	default:
+		return false
+	}
+}
+
+// execCycle supervises an exec display's process, recording its exits and
+// restarting it according to its restart policy. Restarted processes get
+// their window placed and their keys sent again.
+func (kiosk *Kiosk) execCycle(name string) {
+	kiosk.mu.Lock()
+	window, ok := kiosk.windows[name]
+	kiosk.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	kiosk.wg.Add(1)
+	go func() {
+		defer func() {
+			kiosk.wg.Done()
+		}()
+
+		quickExits := 0
+
+		for {
+			kiosk.mu.Lock()
+			exited := window.Exec.exited
+			kiosk.mu.Unlock()
+
+			var err error
+			if exited == nil {
+				// The command failed to start
+				kiosk.mu.Lock()
+				err = errors.New(window.Exec.LastErr)
+				kiosk.mu.Unlock()
+			} else {
+				select {
+				case err = <-exited:
+				case <-kiosk.ctx.Done():
+					// Stopping kills the command's group, wait for that
+					// to finish before the kiosk exits
+					<-exited
+					return
+				}
+			}
+
+			if kiosk.ctx.Err() != nil {
+				return
+			}
+
+			exitCode := 0
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				exitCode = exitErr.ExitCode()
+			} else if err != nil {
+				exitCode = -1
+			}
+
+			kiosk.mu.Lock()
+			ran := time.Since(window.Exec.Started)
+			window.Exec.Running = false
+			window.WindowID = ""
+			window.Exec.ExitCode = exitCode
+			window.Exec.LastExit = time.Now()
+			window.Exec.exited = nil
+			if err != nil {
+				window.Exec.LastErr = err.Error()
+			} else {
+				window.Exec.LastErr = ""
+			}
+			kiosk.mu.Unlock()
+
+			policy := window.Config.Exec.Restart
+			if !shouldRestart(policy, exitCode) {
+				log.Printf("[%s] Command exited with code %d after %v, not restarting (restart policy %q)", name, exitCode, ran.Round(time.Second), policy)
+				<-kiosk.ctx.Done()
+				if exited != nil {
+					kiosk.mu.Lock()
+					pid := window.Exec.PID
+					kiosk.mu.Unlock()
+					killProcessGroup(pid)
+				}
+				return
+			}
+
+			// Don't leave anything the command spawned running next to the
+			// next copy
+			if exited != nil {
+				kiosk.mu.Lock()
+				pid := window.Exec.PID
+				kiosk.mu.Unlock()
+				killProcessGroup(pid)
+			}
+
+			if ran >= stableRunTime {
+				quickExits = 0
+			}
+			delay := restartDelay(window.Config.Exec, quickExits)
+			quickExits++
+
+			log.Printf("[%s] Command exited with code %d after %v, restarting in %v", name, exitCode, ran.Round(time.Second), delay)
+
+			select {
+			case <-time.After(delay):
+			case <-kiosk.ctx.Done():
+				return
+			}
+
+			kiosk.mu.Lock()
+			window.Exec.Restarts++
+			kiosk.mu.Unlock()
+
+			kiosk.launchCustom(name)
+			if kiosk.ctx.Err() != nil {
+				return
+			}
+
+			if err := kiosk.placeWindow(name); err != nil {
+				return
+			}
+
+			if err := kiosk.sendStartupKeys(name); err != nil {
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"kiosk/internal/config"
+)
+
+// geometryTolerance is the number of pixels a window may differ from its
+// configured position or size before it is considered to have drifted. Window
+// managers report geometry with or without decorations, so an exact match is
+// not reliable.
+const geometryTolerance = 32
+
+type WindowGeometry struct {
+	X, Y          int
+	Width, Height int
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func (kiosk *Kiosk) xdotoolGetGeometry(name string, windowID string) (WindowGeometry, error) {
+	var geom WindowGeometry
+
+	out, err := kiosk.displayCommand(kiosk.ctx, name, "xdotool", "getwindowgeometry", "--shell", windowID).Output()
+	if err != nil {
+		return geom, fmt.Errorf("could not get geometry of window %s: %w", windowID, err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+
+		switch key {
+		case "X":
+			geom.X = n
+		case "Y":
+			geom.Y = n
+		case "WIDTH":
+			geom.Width = n
+		case "HEIGHT":
+			geom.Height = n
+		}
+	}
+
+	return geom, nil
+}
+
+func (kiosk *Kiosk) xdotoolGetDisplayGeometry(name string) (int, int, error) {
+	out, err := kiosk.displayCommand(kiosk.ctx, name, "xdotool", "getdisplaygeometry").Output()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected display geometry: %q", string(out))
+	}
+
+	width, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	height, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return width, height, nil
+}
+
+// isFullscreen reports whether the window has the _NET_WM_STATE_FULLSCREEN
+// hint set. If xprop is unavailable the window is compared against the size
+// of the X display instead, which only is a guess: the second result is false
+// then, as a window fullscreen on one output of a multi-monitor setup is
+// smaller than the display.
+func (kiosk *Kiosk) isFullscreen(name string, windowID string, geom WindowGeometry) (bool, bool, error) {
+	if binPresent("xprop") {
+		out, err := kiosk.displayCommand(kiosk.ctx, name, "xprop", "-id", windowID, "_NET_WM_STATE").Output()
+		if err != nil {
+			return false, false, fmt.Errorf("could not get state of window %s: %w", windowID, err)
+		}
+
+		return strings.Contains(string(out), "_NET_WM_STATE_FULLSCREEN"), true, nil
+	}
+
+	width, height, err := kiosk.xdotoolGetDisplayGeometry(name)
+	if err != nil {
+		return false, false, err
+	}
+
+	return geom.Width >= width && geom.Height >= height, false, nil
+}
+
+func (kiosk *Kiosk) resizeWindow(name string, width, height int) {
+	windowID, ok := kiosk.windowID(name)
+	if !ok {
+		return
+	}
+
+	w, h := strconv.Itoa(width), strconv.Itoa(height)
+
+	log.Printf("[%s] Resizing window %s to %sx%s\n", name, windowID, w, h)
+	err := kiosk.displayCommand(kiosk.ctx, name, "xdotool", "windowsize", windowID, w, h).Run()
+	if err != nil {
+		log.Printf("[%s] Error resizing window %s: %v", name, windowID, err)
+	}
+}
+
+// windowSize returns the size a display's window should have. Custom exec
+// windows are only sized if the display sets its own width and height.
+func (kiosk *Kiosk) windowSize(window *DisplayState) (int, int, bool) {
+	if window.Config.Exec.Command != "" && (window.Config.Width <= 0 || window.Config.Height <= 0) {
+		return 0, 0, false
+	}
+
+	return kiosk.cfg.DisplayWindowSize(window.Config)
+}
+
+func (kiosk *Kiosk) applyWindowSize(name string) {
+	window, ok := kiosk.windows[name]
+	if !ok {
+		log.Printf("[%s] No window state found for %s", name, name)
+		return
+	}
+
+	width, height, ok := kiosk.windowSize(window)
+	if !ok {
+		return
+	}
+
+	kiosk.resizeWindow(name, width, height)
+}
+
+// rotateOutput applies the configured xrandr rotation to a display's output.
+func (kiosk *Kiosk) rotateOutput(name string) {
+	window, ok := kiosk.windows[name]
+	if !ok {
+		log.Printf("[%s] No window state found for %s", name, name)
+		return
+	}
+
+	output, rotation := window.Config.Output, window.Config.Rotation
+	if output == "" || rotation == "" {
+		return
+	}
+
+	if !config.ValidRotation(rotation) {
+		log.Printf("[%s] Invalid rotation %q, expected one of %v", name, rotation, config.Rotations)
+		return
+	}
+
+	if !binPresent("xrandr") {
+		log.Printf("[%s] xrandr not found, not rotating output %s", name, output)
+		return
+	}
+
+	log.Printf("[%s] Rotating output %s to %s\n", name, output, rotation)
+	err := kiosk.displayCommand(kiosk.ctx, name, "xrandr", "--output", output, "--rotate", rotation).Run()
+	if err != nil {
+		log.Printf("[%s] Error rotating output %s: %v", name, output, err)
+	}
+}
+
+// countCorrection records a geometry correction and returns the new count.
+func (kiosk *Kiosk) countCorrection(window *DisplayState) int {
+	kiosk.mu.Lock()
+	defer kiosk.mu.Unlock()
+
+	window.GeometryCorrections++
+	return window.GeometryCorrections
+}
+
+// correctGeometry compares the actual state of a display's window against its
+// configuration and restores it if it has drifted.
+func (kiosk *Kiosk) correctGeometry(name string) {
+	kiosk.mu.Lock()
+	window, ok := kiosk.windows[name]
+	var windowID string
+	if ok {
+		windowID = window.WindowID
+	}
+	kiosk.mu.Unlock()
+
+	// Exec displays have no window while their command is restarting
+	if windowID == "" {
+		return
+	}
+
+	geom, err := kiosk.xdotoolGetGeometry(name, windowID)
+	if err != nil {
+		log.Printf("[%s] Error checking window geometry: %v", name, err)
+		return
+	}
+
+	fullscreen, certain, err := kiosk.isFullscreen(name, windowID, geom)
+	if err != nil {
+		log.Printf("[%s] Error checking fullscreen state: %v", name, err)
+		return
+	}
+
+	if window.Config.Fullscreen {
+		// F11 toggles fullscreen, so it is only sent when the window is
+		// known not to be fullscreen
+		if !fullscreen && certain {
+			log.Printf("[%s] Window %s is no longer fullscreen, restoring (correction #%d)", name, windowID, kiosk.countCorrection(window))
+			kiosk.sendFullscreen(name)
+		}
+		return
+	}
+
+	// A window that was fullscreened by a send key sequence can't be compared
+	// against its configured position.
+	if fullscreen {
+		return
+	}
+
+	if absInt(geom.X-window.Config.X) > geometryTolerance || absInt(geom.Y-window.Config.Y) > geometryTolerance {
+		log.Printf("[%s] Window %s drifted to %d:%d, restoring to %d:%d (correction #%d)", name, windowID, geom.X, geom.Y, window.Config.X, window.Config.Y, kiosk.countCorrection(window))
+		kiosk.moveWindow(name)
+	}
+
+	width, height, ok := kiosk.windowSize(window)
+	if !ok {
+		return
+	}
+
+	if absInt(geom.Width-width) > geometryTolerance || absInt(geom.Height-height) > geometryTolerance {
+		log.Printf("[%s] Window %s resized to %dx%d, restoring to %dx%d (correction #%d)", name, windowID, geom.Width, geom.Height, width, height, kiosk.countCorrection(window))
+		kiosk.resizeWindow(name, width, height)
+	}
+}
+
+func (kiosk *Kiosk) geometryWatcher(name string) {
+	interval := time.Duration(kiosk.cfg.GeometryCheckInterval) * time.Second
+	if interval <= 0 {
+		return
+	}
+
+	kiosk.mu.Lock()
+	_, ok := kiosk.windows[name]
+	kiosk.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	kiosk.wg.Add(1)
+	go func() {
+		defer kiosk.wg.Done()
+
+		for {
+			select {
+			case <-time.After(interval):
+			case <-kiosk.ctx.Done():
+				return
+			}
+
+			kiosk.correctGeometry(name)
+		}
+	}()
+}
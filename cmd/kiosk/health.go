@@ -0,0 +1,362 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"kiosk/internal/config"
+)
+
+const (
+	defaultRetryInterval    = 10 * time.Second
+	defaultMaxRetryInterval = 5 * time.Minute
+)
+
+const fallbackPage = `<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="UTF-8" />
+    <title>Temporarily unavailable</title>
+    <style>
+      html, body { height: 100%%; margin: 0; }
+      body {
+        display: flex; flex-direction: column; align-items: center; justify-content: center;
+        background: #1b1f27; color: #e6e6e6; font-family: sans-serif;
+      }
+      h1 { font-size: 4vw; font-weight: 300; margin: 0 0 1rem; }
+      p { font-size: 1.5vw; opacity: 0.6; }
+    </style>
+  </head>
+  <body>
+    <h1>Temporarily unavailable</h1>
+    <p>%s</p>
+  </body>
+</html>`
+
+// isAbortError reports whether a navigation error is just a navigation being
+// replaced by another one.
+func isAbortError(errorText string) bool {
+	return errorText == "net::ERR_ABORTED"
+}
+
+// markTabFailed records a load failure and schedules the next retry using an
+// exponential backoff.
+func (kiosk *Kiosk) markTabFailed(name string, tab *TabState, reason string) {
+	kiosk.markTabFailedWith(name, tab, reason, kiosk.cfg.Failure.Action)
+}
+
+// markTabFailedWith is markTabFailed with an explicit failure action, fallback
+// or skip.
+func (kiosk *Kiosk) markTabFailedWith(name string, tab *TabState, reason string, action string) {
+	kiosk.mu.Lock()
+
+	// Already broken, e.g. a failed navigation reported by several events
+	if tab.Failed {
+		kiosk.mu.Unlock()
+		return
+	}
+
+	retry := defaultRetryInterval
+	if kiosk.cfg.Failure.RetryInterval > 0 {
+		retry = time.Duration(kiosk.cfg.Failure.RetryInterval) * time.Second
+	}
+
+	maxRetry := defaultMaxRetryInterval
+	if kiosk.cfg.Failure.MaxRetryInterval > 0 {
+		maxRetry = time.Duration(kiosk.cfg.Failure.MaxRetryInterval) * time.Second
+	}
+
+	for i := 0; i < tab.Failures && retry < maxRetry; i++ {
+		retry *= 2
+	}
+	if retry > maxRetry {
+		retry = maxRetry
+	}
+
+	tab.Failed = true
+	tab.Failures++
+	tab.LastError = reason
+	tab.NextRetry = time.Now().Add(retry)
+	tab.FailureAction = action
+	failures := tab.Failures
+
+	fallback := action != config.FailureSkip
+	if fallback {
+		tab.ShowingFallback = true
+		tab.needsNavigate = true
+	}
+
+	kiosk.mu.Unlock()
+
+	log.Printf("[%s] Tab %s failed (%s), failure #%d, retrying in %v", name, tab.URL, reason, failures, retry)
+
+	if fallback {
+		go kiosk.showFallback(name, tab, reason)
+	}
+}
+
+// markTabRecovered clears a tab's failure once a retry loaded it. Tabs with a
+// health check keep their failure count, and with it their backoff, until the
+// check passes.
+func (kiosk *Kiosk) markTabRecovered(name string, tab *TabState) {
+	kiosk.mu.Lock()
+	failures := tab.Failures
+	tab.Failed = false
+	tab.LastError = ""
+	pending := tab.HealthCheck != nil && tab.HealthCheck.Action != config.HealthAlert
+	if !pending {
+		tab.Failures = 0
+	}
+	kiosk.mu.Unlock()
+
+	if failures > 0 && !pending {
+		log.Printf("[%s] Tab %s recovered after %d failures", name, tab.URL, failures)
+	}
+}
+
+func (kiosk *Kiosk) showFallback(name string, tab *TabState, reason string) {
+	fallbackURL := kiosk.cfg.Failure.FallbackURL
+	if fallbackURL == "" {
+		page := fmt.Sprintf(fallbackPage, html.EscapeString(reason))
+		fallbackURL = "data:text/html;charset=utf-8," + url.PathEscape(page)
+	}
+
+	session, err := kiosk.tabSession(name, tab)
+	if err != nil {
+		log.Printf("[%s] Error showing fallback for tab %s: %v", name, tab.URL, err)
+		return
+	}
+
+	err = session.Call("Page.navigate", map[string]interface{}{"url": fallbackURL}, nil)
+	if err != nil {
+		log.Printf("[%s] Error showing fallback for tab %s: %v", name, tab.URL, err)
+	}
+}
+
+// tabUsable reports whether a tab should be shown and whether it is healthy.
+// Broken tabs due for a retry are reloaded first.
+func (kiosk *Kiosk) tabUsable(name string, tab *TabState) (bool, bool) {
+	kiosk.mu.Lock()
+	failed, due, action := tab.Failed, time.Now().After(tab.NextRetry), tab.FailureAction
+	kiosk.mu.Unlock()
+
+	if !failed {
+		return true, true
+	}
+
+	if due {
+		log.Printf("[%s] Retrying broken tab %s", name, tab.URL)
+
+		kiosk.mu.Lock()
+		tab.Failed = false
+		tab.ShowingFallback = false
+		kiosk.mu.Unlock()
+
+		kiosk.refreshTabAndWait(tab, name)
+
+		kiosk.mu.Lock()
+		failed = tab.Failed
+		kiosk.mu.Unlock()
+
+		if !failed {
+			kiosk.markTabRecovered(name, tab)
+			return true, true
+		}
+	}
+
+	return action != config.FailureSkip, false
+}
+
+// registerHealth watches the tab's main document for failed loads, error
+// responses and renderer crashes.
+func (kiosk *Kiosk) registerHealth(name string, tab *TabState, session *CDPSession) error {
+	if err := session.Call("Network.enable", nil, nil); err != nil {
+		return err
+	}
+
+	if err := session.Call("Inspector.enable", nil, nil); err != nil {
+		return err
+	}
+
+	// The main frame of a page target shares the target's ID
+	var mu sync.Mutex
+	documents := make(map[string]bool)
+
+	session.On("Network.requestWillBeSent", func(params json.RawMessage) {
+		var event struct {
+			RequestID string `json:"requestId"`
+			FrameID   string `json:"frameId"`
+			Type      string `json:"type"`
+		}
+		if err := json.Unmarshal(params, &event); err != nil {
+			return
+		}
+
+		if event.Type == "Document" && event.FrameID == tab.ID {
+			mu.Lock()
+			documents[event.RequestID] = true
+			mu.Unlock()
+		}
+	})
+
+	session.On("Network.responseReceived", func(params json.RawMessage) {
+		var event struct {
+			RequestID string `json:"requestId"`
+			Response  struct {
+				URL        string `json:"url"`
+				Status     int    `json:"status"`
+				StatusText string `json:"statusText"`
+			} `json:"response"`
+		}
+		if err := json.Unmarshal(params, &event); err != nil {
+			return
+		}
+
+		mu.Lock()
+		isDocument := documents[event.RequestID]
+		delete(documents, event.RequestID)
+		mu.Unlock()
+
+		if isDocument && event.Response.Status >= 400 {
+			kiosk.markTabFailed(name, tab, fmt.Sprintf("HTTP %d %s", event.Response.Status, event.Response.StatusText))
+		}
+	})
+
+	session.On("Network.loadingFailed", func(params json.RawMessage) {
+		var event struct {
+			RequestID string `json:"requestId"`
+			ErrorText string `json:"errorText"`
+			Canceled  bool   `json:"canceled"`
+		}
+		if err := json.Unmarshal(params, &event); err != nil {
+			return
+		}
+
+		mu.Lock()
+		isDocument := documents[event.RequestID]
+		delete(documents, event.RequestID)
+		mu.Unlock()
+
+		if isDocument && !event.Canceled && !isAbortError(event.ErrorText) {
+			kiosk.markTabFailed(name, tab, event.ErrorText)
+		}
+	})
+
+	session.On("Inspector.targetCrashed", func(json.RawMessage) {
+		// A crash also takes down the fallback page
+		kiosk.mu.Lock()
+		tab.Failed = false
+		tab.ShowingFallback = false
+		kiosk.mu.Unlock()
+
+		kiosk.markTabFailed(name, tab, "renderer crashed")
+	})
+
+	return nil
+}
+
+const defaultHealthCheckDelay = 5 * time.Second
+
+const healthCheckScript = `(async function (selector, textAbsent, expression) {
+  if (selector && !document.querySelector(selector)) return "selector " + selector + " not found";
+  var text = document.body ? document.body.innerText : "";
+  for (var i = 0; i < textAbsent.length; i++) {
+    if (text.indexOf(textAbsent[i]) !== -1) return "found text " + JSON.stringify(textAbsent[i]);
+  }
+  if (expression) {
+    var ok = await (0, eval)(expression);
+    if (ok !== true) return "expression returned " + JSON.stringify(ok);
+  }
+  return "";
+})`
+
+// registerHealthCheck checks the tab's content after every page load.
+func (kiosk *Kiosk) registerHealthCheck(name string, tab *TabState, session *CDPSession) {
+	if tab.HealthCheck == nil {
+		return
+	}
+
+	session.On("Page.loadEventFired", func(json.RawMessage) {
+		go kiosk.runHealthCheck(name, tab, session)
+	})
+}
+
+func (kiosk *Kiosk) runHealthCheck(name string, tab *TabState, session *CDPSession) {
+	check := tab.HealthCheck
+
+	delay := defaultHealthCheckDelay
+	if check.Delay > 0 {
+		delay = time.Duration(check.Delay) * time.Second
+	}
+
+	select {
+	case <-time.After(delay):
+	case <-session.Done():
+		return
+	}
+
+	kiosk.mu.Lock()
+	showingFallback := tab.ShowingFallback
+	kiosk.mu.Unlock()
+
+	if showingFallback {
+		return
+	}
+
+	textAbsent := check.TextAbsent
+	if textAbsent == nil {
+		textAbsent = []string{}
+	}
+
+	args, _ := json.Marshal([]interface{}{check.Selector, textAbsent, check.Expression})
+	expression := fmt.Sprintf("%s(...%s)", healthCheckScript, args)
+
+	var problem string
+	if err := session.Evaluate(expression, &problem); err != nil {
+		problem = err.Error()
+	}
+
+	kiosk.mu.Lock()
+	tab.LastHealthCheck = time.Now()
+	tab.HealthError = problem
+	loadFailures := tab.Failures
+	if problem != "" {
+		tab.HealthFailures++
+	} else {
+		tab.HealthFailures = 0
+		tab.Failures = 0
+	}
+	failures := tab.HealthFailures
+	kiosk.mu.Unlock()
+
+	if problem == "" {
+		if loadFailures > 0 {
+			log.Printf("[%s] Tab %s recovered after %d failures, health check passed", name, tab.URL, loadFailures)
+		}
+		return
+	}
+
+	switch check.Action {
+	case config.HealthSkip:
+		log.Printf("[%s] Health check failed for tab %s (%s), skipping it", name, tab.URL, problem)
+		kiosk.markTabFailedWith(name, tab, "health check: "+problem, config.FailureSkip)
+	case config.HealthAlert:
+		log.Printf("[%s] ALERT: health check failed for tab %s (%s), failure #%d", name, tab.URL, problem, failures)
+	default:
+		// Back off like a broken page if reloading doesn't help
+		if failures > 1 {
+			kiosk.markTabFailed(name, tab, "health check: "+problem)
+			return
+		}
+
+		log.Printf("[%s] Health check failed for tab %s (%s), reloading", name, tab.URL, problem)
+		if err := kiosk.navigateChromeTab(name, tab); err != nil {
+			log.Printf("[%s] Error reloading tab %s: %v", name, tab.URL, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// loadInjection returns the contents of an injectCSS/injectJS value, which is
+// either a path to a file or the inline snippet itself. A value that looks like
+// a path but can't be read is an error rather than being injected as code.
+func loadInjection(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.ContainsAny(value, "\n{};()") {
+		return value, nil
+	}
+
+	looksLikePath := strings.Contains(value, "/") || strings.HasSuffix(value, ".js") || strings.HasSuffix(value, ".css")
+
+	info, err := os.Stat(value)
+	if err != nil {
+		if looksLikePath {
+			return "", fmt.Errorf("failed to read %s: %w", value, err)
+		}
+		return value, nil
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", value)
+	}
+
+	data, err := os.ReadFile(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", value, err)
+	}
+
+	return string(data), nil
+}
+
+// injectionScripts builds the scripts applying a tab's custom CSS and
+// JavaScript. Each script only runs once per document so it can be evaluated
+// again after a refresh without doubling up.
+func injectionScripts(tab *TabState) ([]string, error) {
+	scripts := []string{}
+	var errs []error
+
+	css, err := loadInjection(tab.InjectCSS)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("injectCSS: %w", err))
+	}
+
+	if css != "" {
+		source, _ := json.Marshal(css)
+		scripts = append(scripts, fmt.Sprintf(`(function () {
+  if (window.__kioskInjectedCSS) return;
+  window.__kioskInjectedCSS = true;
+  var add = function () {
+    var style = document.createElement("style");
+    style.setAttribute("data-kiosk", "inject");
+    style.textContent = %s;
+    (document.head || document.documentElement).appendChild(style);
+  };
+  if (document.documentElement) add();
+  else document.addEventListener("DOMContentLoaded", add);
+})();`, source))
+	}
+
+	js, err := loadInjection(tab.InjectJS)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("injectJS: %w", err))
+	}
+
+	if js != "" {
+		scripts = append(scripts, fmt.Sprintf(`(function () {
+  if (window.__kioskInjectedJS) return;
+  window.__kioskInjectedJS = true;
+%s
+})();`, js))
+	}
+
+	return scripts, errors.Join(errs...)
+}
+
+// registerInjections adds the tab's custom CSS and JavaScript to every new
+// document loaded in the session, and applies it to the current one.
+func (kiosk *Kiosk) registerInjections(name string, tab *TabState, session *CDPSession) error {
+	// Injections that can be loaded are applied even if another one failed
+	scripts, loadErr := injectionScripts(tab)
+
+	for _, script := range scripts {
+		err := session.Call("Page.addScriptToEvaluateOnNewDocument", map[string]interface{}{
+			"source": script,
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(scripts) > 0 {
+		log.Printf("[%s] Registered %d injected scripts for tab %s", name, len(scripts), tab.URL)
+	}
+
+	kiosk.applyInjections(name, tab, session)
+	return loadErr
+}
+
+// applyInjections evaluates the tab's custom CSS and JavaScript in the
+// document currently loaded.
+func (kiosk *Kiosk) applyInjections(name string, tab *TabState, session *CDPSession) {
+	scripts, _ := injectionScripts(tab)
+
+	for _, script := range scripts {
+		err := session.Call("Runtime.evaluate", map[string]interface{}{
+			"expression": script,
+		}, nil)
+		if err != nil {
+			log.Printf("[%s] Error injecting into tab %s: %v", name, tab.URL, err)
+		}
+	}
+}
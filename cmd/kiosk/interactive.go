@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/url"
+	"time"
+
+	"kiosk/internal/config"
+)
+
+const inputBinding = "__kioskInputBinding"
+
+// inputScript reports user input to the kiosk through a Runtime binding, at
+// most once a second.
+const inputScript = `(function () {
+  if (window.__kioskInput) return;
+  window.__kioskInput = true;
+  var last = 0;
+  function report() {
+    var now = Date.now();
+    if (now - last < 1000) return;
+    last = now;
+    if (window.` + inputBinding + `) window.` + inputBinding + `("");
+  }
+  ["pointerdown", "touchstart", "keydown", "wheel"].forEach(function (type) {
+    window.addEventListener(type, report, { capture: true, passive: true });
+  });
+})();`
+
+func idleTimeout(display *DisplayState) time.Duration {
+	if display.Config.Interactive.IdleTimeout > 0 {
+		return time.Duration(display.Config.Interactive.IdleTimeout) * time.Second
+	}
+
+	return config.DefaultIdleTimeout * time.Second
+}
+
+// tracksInput reports whether user input in a tab is needed, either for the
+// display's interactive mode or for the return timer of its navigation lock.
+func tracksInput(display *DisplayState, tab *TabState) bool {
+	return display.Config.Interactive != nil || (tab.NavigationLock != nil && tab.NavigationLock.ReturnAfter > 0)
+}
+
+// registerInteractive reports user input in the tab's pages to the display's
+// idle tracking.
+func (kiosk *Kiosk) registerInteractive(name string, tab *TabState, session *CDPSession) error {
+	kiosk.mu.Lock()
+	display, ok := kiosk.windows[name]
+	kiosk.mu.Unlock()
+
+	if !ok || !tracksInput(display, tab) {
+		return nil
+	}
+
+	session.On("Runtime.bindingCalled", func(params json.RawMessage) {
+		var event struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(params, &event); err != nil || event.Name != inputBinding {
+			return
+		}
+
+		kiosk.noteInput(name, display, tab)
+	})
+
+	if err := session.Call("Runtime.enable", nil, nil); err != nil {
+		return err
+	}
+
+	if err := session.Call("Runtime.addBinding", map[string]interface{}{"name": inputBinding}, nil); err != nil {
+		return err
+	}
+
+	if err := session.Call("Page.addScriptToEvaluateOnNewDocument", map[string]interface{}{"source": inputScript}, nil); err != nil {
+		return err
+	}
+
+	return session.Call("Runtime.evaluate", map[string]interface{}{"expression": inputScript}, nil)
+}
+
+func (kiosk *Kiosk) noteInput(name string, display *DisplayState, tab *TabState) {
+	kiosk.mu.Lock()
+	defer kiosk.mu.Unlock()
+
+	// Input dispatched by the display's own actions
+	if display.runningActions > 0 || time.Now().Before(display.ignoreInputUntil) {
+		return
+	}
+
+	tab.lastInput = time.Now()
+
+	if display.Config.Interactive == nil {
+		return
+	}
+
+	if !display.Interacting {
+		log.Printf("[%s] User input detected, pausing rotation", name)
+	}
+
+	display.Interacting = true
+	display.LastInput = time.Now()
+}
+
+// waitDwell waits for the tab's dwell time. On interactive displays the wait
+// is extended while the display is used, and reports true once it has been
+// idle for its idle timeout so the display can be reset.
+func (kiosk *Kiosk) waitDwell(display *DisplayState, dwell time.Duration) (bool, error) {
+	if display.Config.Interactive == nil {
+		select {
+		case <-time.After(dwell):
+			return false, nil
+		case <-kiosk.ctx.Done():
+			return false, kiosk.ctx.Err()
+		}
+	}
+
+	deadline := time.Now().Add(dwell)
+	idle := idleTimeout(display)
+
+	for {
+		kiosk.mu.Lock()
+		interacting, lastInput := display.Interacting, display.LastInput
+		if interacting && time.Since(lastInput) >= idle {
+			display.Interacting = false
+		}
+		kiosk.mu.Unlock()
+
+		wait := time.Until(deadline)
+		if interacting {
+			wait = time.Until(lastInput.Add(idle))
+			if wait <= 0 {
+				return true, nil
+			}
+		} else if wait <= 0 {
+			return false, nil
+		}
+
+		// Check again regularly so input arriving meanwhile is noticed
+		if wait > time.Second {
+			wait = time.Second
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-kiosk.ctx.Done():
+			return false, kiosk.ctx.Err()
+		}
+	}
+}
+
+// resetInteractive clears the session data of an interactive display after it
+// became idle, restores the configured cookies and loads every tab's URL
+// again.
+func (kiosk *Kiosk) resetInteractive(name string, display *DisplayState) {
+	log.Printf("[%s] Idle after user input, resetting display", name)
+
+	if !display.Config.Interactive.KeepSession {
+		kiosk.clearSessionData(name, display)
+	}
+
+	for _, tab := range display.Tabs {
+		session, err := kiosk.tabSession(name, tab)
+		if err != nil {
+			log.Printf("[%s] Error resetting tab %s: %v", name, tab.URL, err)
+			continue
+		}
+
+		if !display.Config.Interactive.KeepSession {
+			if err := kiosk.setCookies(tab, session); err != nil {
+				log.Printf("[%s] Error restoring cookies of tab %s: %v", name, tab.URL, err)
+			}
+		}
+
+		// Visitors may have browsed away, so load the tab's URL itself
+		kiosk.mu.Lock()
+		tab.needsNavigate = true
+		kiosk.mu.Unlock()
+
+		kiosk.refreshTabAndWait(tab, name)
+	}
+}
+
+// clearSessionData removes the cookies of the display's browser and the
+// storage of its tabs' origins.
+func (kiosk *Kiosk) clearSessionData(name string, display *DisplayState) {
+	clearedCookies := false
+
+	for _, tab := range display.Tabs {
+		session, err := kiosk.tabSession(name, tab)
+		if err != nil {
+			log.Printf("[%s] Error clearing session data of tab %s: %v", name, tab.URL, err)
+			continue
+		}
+
+		// Cookies are shared by all tabs of the browser
+		if !clearedCookies {
+			if err := session.Call("Network.clearBrowserCookies", nil, nil); err != nil {
+				log.Printf("[%s] Error clearing cookies: %v", name, err)
+			}
+			clearedCookies = true
+		}
+
+		u, err := url.Parse(kiosk.pageURL(name, tab))
+		if err != nil || u.Host == "" {
+			continue
+		}
+
+		err = session.Call("Storage.clearDataForOrigin", map[string]interface{}{
+			"origin":       u.Scheme + "://" + u.Host,
+			"storageTypes": "all",
+		}, nil)
+		if err != nil {
+			log.Printf("[%s] Error clearing storage of tab %s: %v", name, tab.URL, err)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -18,9 +19,6 @@ import (
 
 	"kiosk/internal/config"
 	"kiosk/internal/web"
-
-	"nhooyr.io/websocket"
-	"nhooyr.io/websocket/wsjson"
 )
 
 type TabState struct {
@@ -28,14 +26,49 @@ type TabState struct {
 	ID          string
 	LastRefresh int64
 	WSURL       string
-	WSConn      *websocket.Conn
+	Session     *CDPSession
+
+	Failed          bool
+	Failures        int
+	LastError       string
+	NextRetry       time.Time
+	ShowingFallback bool
+	FailureAction   string
+
+	HealthFailures  int
+	HealthError     string
+	LastHealthCheck time.Time
+
+	Preloading  bool
+	PreloadedAt time.Time
+	preloadDone chan struct{}
+	preloaded   bool
+
+	needsNavigate bool
+
+	launchActionsDone bool
+
+	lastInput time.Time
+
+	sessionMu sync.Mutex
+	refreshMu sync.Mutex
+	loginMu   sync.Mutex
 }
 
 type DisplayState struct {
-	Config    config.DisplayConfig
-	DebugPort int
-	Tabs      []*TabState
-	WindowID  string
+	Config              config.DisplayConfig
+	DebugPort           int
+	Tabs                []*TabState
+	WindowID            string
+	GeometryCorrections int
+	ActiveTab           int
+	Ticker              string
+	Interacting         bool
+	LastInput           time.Time
+	Exec                ExecState
+
+	runningActions   int
+	ignoreInputUntil time.Time
 }
 
 type RequestID struct {
@@ -55,6 +88,8 @@ type Kiosk struct {
 	requestID   *RequestID
 	cfg         config.Config
 	cfgFilename string
+	secrets     map[string]string
+	webPort     int
 
 	mu      sync.Mutex
 	windows map[string]*DisplayState
@@ -78,6 +113,25 @@ func binPresent(bin string) bool {
 	return true
 }
 
+// displayEnv returns the environment for processes acting on a display's X
+// server, or nil to inherit the kiosk's own DISPLAY.
+func (kiosk *Kiosk) displayEnv(name string) []string {
+	window, ok := kiosk.windows[name]
+	if !ok || window.Config.XDisplay == "" {
+		return nil
+	}
+
+	return append(os.Environ(), fmt.Sprintf("DISPLAY=%s", window.Config.XDisplay))
+}
+
+// displayCommand creates a command that runs against the X server of the
+// named display.
+func (kiosk *Kiosk) displayCommand(ctx context.Context, name string, bin string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Env = kiosk.displayEnv(name)
+	return cmd
+}
+
 func ensureDeps(bins []string) {
 	for _, b := range bins {
 		if _, err := exec.LookPath(b); err != nil {
@@ -110,6 +164,59 @@ type KioskWeb struct {
 	Parent *Kiosk
 }
 
+func (e *KioskWeb) Status() []web.DisplayStatus {
+	kiosk := e.Parent
+
+	kiosk.mu.Lock()
+	defer kiosk.mu.Unlock()
+
+	status := []web.DisplayStatus{}
+	for _, display := range kiosk.cfg.Displays {
+		window, ok := kiosk.windows[display.Name]
+		if !ok {
+			continue
+		}
+
+		ds := web.DisplayStatus{
+			Name:                display.Name,
+			WindowID:            window.WindowID,
+			GeometryCorrections: window.GeometryCorrections,
+			Interacting:         window.Interacting,
+		}
+
+		if display.Exec.Command != "" {
+			ds.Exec = &web.ExecStatus{
+				Command:  display.Exec.Command,
+				PID:      window.Exec.PID,
+				Running:  window.Exec.Running,
+				Restarts: window.Exec.Restarts,
+				ExitCode: window.Exec.ExitCode,
+				LastExit: window.Exec.LastExit,
+				LastErr:  window.Exec.LastErr,
+			}
+		}
+
+		for i, tab := range window.Tabs {
+			ds.Tabs = append(ds.Tabs, web.TabStatus{
+				URL:             tab.URL,
+				Active:          i == window.ActiveTab,
+				Failed:          tab.Failed,
+				Failures:        tab.Failures,
+				LastError:       tab.LastError,
+				HealthError:     tab.HealthError,
+				HealthFailures:  tab.HealthFailures,
+				LastHealthCheck: tab.LastHealthCheck,
+				Preloading:      tab.Preloading,
+				PreloadedAt:     tab.PreloadedAt,
+			})
+		}
+
+		status = append(status, ds)
+	}
+
+	return status
+}
+
 func (e *KioskWeb) AddDisplay(display config.DisplayConfig) error {
 	log.Printf("Adding display: %+v", display)
 	return nil
@@ -140,6 +247,10 @@ func (e *KioskWeb) EditTab(displayName string, tab config.TabConfig) error {
 	return nil
 }
 
+func (e *KioskWeb) SetTicker(displayName, text string) error {
+	return e.Parent.SetTicker(displayName, text)
+}
+
 func (e *KioskWeb) ReloadDisplays() error {
 	log.Println("Reloading displays")
 
@@ -157,16 +268,12 @@ func (e *KioskWeb) ReloadDisplays() error {
 	return nil
 }
 
-func main() {
-	ensureDeps([]string{"xdotool", "chromium"})
-
-	kiosk := NewKiosk()
-	kiosk.loadConfig()
+// How long the kiosk waits for displays to stop before exiting
+const shutdownTimeout = 2 * time.Second
 
-	ctx, cancel := context.WithCancel(context.Background())
-	ctxHandler(ctx, cancel)
+func main() {
+	ensureDeps([]string{"xdotool"})
 
-	// Start the web UI
 	portStr := os.Getenv("PORT")
 	if portStr == "" {
 		portStr = "8080"
@@ -176,6 +283,21 @@ func main() {
 		log.Fatalf("Invalid PORT: %s", portStr)
 	}
 
+	kiosk := NewKiosk()
+	kiosk.webPort = port
+	kiosk.loadConfig()
+
+	browser, err := kiosk.browser()
+	if err != nil {
+		log.Fatalf("Missing dependency: %v", err)
+	}
+	log.Printf("Using browser %s", browser.Binary)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctxHandler(ctx, cancel)
+
+	// Start the web UI
+
 	go func() {
 		options := web.KioskWebOptions{
 			Addr:       fmt.Sprintf(":%d", port),
@@ -194,7 +316,7 @@ func main() {
 
 	<-ctx.Done()
 	go func() {
-		time.Sleep(2 * time.Second)
+		time.Sleep(shutdownTimeout)
 		os.Exit(0)
 	}()
 
@@ -210,6 +332,10 @@ func (kiosk *Kiosk) Run(ctx context.Context) error {
 		}
 	}()
 
+	for _, display := range kiosk.cfg.Displays {
+		kiosk.rotateOutput(display.Name)
+	}
+
 	for _, display := range kiosk.cfg.Displays {
 		if display.Exec.Command != "" {
 			kiosk.launchCustom(display.Name)
@@ -219,7 +345,7 @@ func (kiosk *Kiosk) Run(ctx context.Context) error {
 			continue
 		}
 
-		if len(display.Tabs) == 0 {
+		if !kiosk.hasTabs(display.Name) {
 			continue
 		}
 
@@ -230,30 +356,14 @@ func (kiosk *Kiosk) Run(ctx context.Context) error {
 	}
 
 	for _, display := range kiosk.cfg.Displays {
-		kiosk.moveWindow(display.Name)
-		if kiosk.ctx.Err() != nil {
-			return kiosk.ctx.Err()
+		if err := kiosk.placeWindow(display.Name); err != nil {
+			return err
 		}
 	}
 
 	for _, display := range kiosk.cfg.Displays {
-		if display.Fullscreen {
-			kiosk.sendFullscreen(display.Name)
-			if kiosk.ctx.Err() != nil {
-				return kiosk.ctx.Err()
-			}
-		}
-
-		for _, key := range display.Exec.SendKeys {
-			if key == "" {
-				continue
-			}
-
-			delay := time.Duration(display.Exec.DelayBeforeSendKeys) * time.Second
-			kiosk.SendKeyToWindow(display.Name, key, delay)
-			if kiosk.ctx.Err() != nil {
-				return kiosk.ctx.Err()
-			}
+		if err := kiosk.sendStartupKeys(display.Name); err != nil {
+			return err
 		}
 	}
 
@@ -263,16 +373,23 @@ func (kiosk *Kiosk) Run(ctx context.Context) error {
 			continue
 		}
 
-		if len(display.Tabs) == 0 {
+		if !kiosk.hasTabs(display.Name) {
 			continue
 		}
 
 		kiosk.tabCycler(display.Name)
+		kiosk.refreshTimers(display.Name)
 		if kiosk.ctx.Err() != nil {
 			return kiosk.ctx.Err()
 		}
 	}
 
+	for _, display := range kiosk.cfg.Displays {
+		kiosk.geometryWatcher(display.Name)
+		kiosk.tickerWatcher(display.Name)
+		kiosk.actionTimers(display.Name)
+	}
+
 	kiosk.wg.Wait()
 
 	kiosk.mu.Lock()
@@ -286,6 +403,68 @@ func (kiosk *Kiosk) Run(ctx context.Context) error {
 	return nil
 }
 
+// placeWindow moves a display's window into place and sizes it unless it is
+// fullscreen.
+func (kiosk *Kiosk) placeWindow(name string) error {
+	window, ok := kiosk.windows[name]
+	if !ok {
+		return nil
+	}
+	display := window.Config
+
+	kiosk.moveWindow(name)
+	if kiosk.ctx.Err() != nil {
+		return kiosk.ctx.Err()
+	}
+
+	if !display.Fullscreen {
+		kiosk.applyWindowSize(name)
+		if kiosk.ctx.Err() != nil {
+			return kiosk.ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// sendStartupKeys fullscreens a display's window if configured and sends its
+// exec send-keys and launch actions.
+func (kiosk *Kiosk) sendStartupKeys(name string) error {
+	window, ok := kiosk.windows[name]
+	if !ok {
+		return nil
+	}
+	display := window.Config
+
+	if display.Fullscreen {
+		kiosk.sendFullscreen(name)
+		if kiosk.ctx.Err() != nil {
+			return kiosk.ctx.Err()
+		}
+	}
+
+	for _, key := range display.Exec.SendKeys {
+		if key == "" {
+			continue
+		}
+
+		delay := time.Duration(display.Exec.DelayBeforeSendKeys) * time.Second
+		kiosk.SendKeyToWindow(name, key, delay)
+		if kiosk.ctx.Err() != nil {
+			return kiosk.ctx.Err()
+		}
+	}
+
+	if display.Exec.Command != "" && display.Exec.Actions != nil {
+		kiosk.runWindowActions(name, "launch", display.Exec.Actions.Launch)
+		if kiosk.ctx.Err() != nil {
+			return kiosk.ctx.Err()
+		}
+	}
+
+	return nil
+}
+
 func (kiosk *Kiosk) Stop() {
 	kiosk.mu.Lock()
 
@@ -315,6 +494,14 @@ func (kiosk *Kiosk) loadConfig() {
 		log.Fatal(err)
 	}
 
+	kiosk.secrets = nil
+	if kiosk.cfg.SecretsFile != "" {
+		kiosk.secrets, err = config.LoadSecrets(kiosk.cfg.SecretsFile)
+		if err != nil {
+			log.Printf("Error loading secrets: %v", err)
+		}
+	}
+
 	kiosk.windows = make(map[string]*DisplayState)
 
 	for _, display := range kiosk.cfg.Displays {
@@ -325,27 +512,49 @@ func (kiosk *Kiosk) loadConfig() {
 				Tabs:      make([]*TabState, len(display.Tabs)),
 			}
 
+			if display.Overlay != nil {
+				ds.Ticker = display.Overlay.Ticker
+			}
+
 			for i, tab := range display.Tabs {
 				ds.Tabs[i] = &TabState{
 					TabConfig:   tab,
 					ID:          "",
 					LastRefresh: time.Now().Unix(),
 					WSURL:       "",
-					WSConn:      nil,
+					Session:     nil,
+
+					needsNavigate: true,
 				}
 			}
 
+			// Tabs take precedence, a layout is only shown through a single
+			// generated page on displays without any
+			if display.Layout != nil && len(ds.Tabs) > 0 {
+				log.Printf("[%s] Display has both tabs and a layout, ignoring the layout (remove the tabs to show it)", display.Name)
+			}
+			if display.Layout != nil && len(ds.Tabs) == 0 {
+				ds.Tabs = append(ds.Tabs, &TabState{
+					TabConfig: config.TabConfig{
+						URL:       kiosk.layoutURL(display.Name),
+						DwellTime: layoutDwellTime,
+					},
+					LastRefresh:   time.Now().Unix(),
+					needsNavigate: true,
+				})
+			}
+
 			kiosk.windows[display.Name] = ds
 		}
 	}
 }
 
-func (kiosk *Kiosk) xdotoolSearchVisible(searchName string) ([]string, error) {
+func (kiosk *Kiosk) xdotoolSearchVisible(name string, searchName string) ([]string, error) {
 	if searchName == "" {
 		searchName = ".*" // Default to all visible windows
 	}
 
-	out, err := exec.Command("xdotool", "search", "--onlyvisible", "--name", searchName).Output()
+	out, err := kiosk.displayCommand(context.Background(), name, "xdotool", "search", "--onlyvisible", "--name", searchName).Output()
 	if err != nil {
 		return []string{}, nil
 	}
@@ -374,6 +583,27 @@ func (kiosk *Kiosk) xdotoolSearchVisible(searchName string) ([]string, error) {
 	return result, nil
 }
 
+// existingWindowIDs returns the window IDs already claimed by displays on the
+// same X display as name. Window IDs are only unique per X server.
+func (kiosk *Kiosk) existingWindowIDs(name string) map[string]bool {
+	kiosk.mu.Lock()
+	defer kiosk.mu.Unlock()
+
+	xDisplay := ""
+	if window, ok := kiosk.windows[name]; ok {
+		xDisplay = window.Config.XDisplay
+	}
+
+	existing := make(map[string]bool)
+	for _, w := range kiosk.windows {
+		if w.WindowID != "" && w.Config.XDisplay == xDisplay {
+			existing[w.WindowID] = true
+		}
+	}
+
+	return existing
+}
+
 func (kiosk *Kiosk) xdotoolFindLatestWindowID(name string, beforeExecIDs []string, afterExecIDs []string) (string, error) {
 	if len(afterExecIDs) == 0 {
 		return "", fmt.Errorf("[%s] No visible windows found", name)
@@ -398,13 +628,8 @@ func (kiosk *Kiosk) xdotoolFindLatestWindowID(name string, beforeExecIDs []strin
 		return "", fmt.Errorf("[%s] No new window found after exec", name)
 	}
 
-	// Gather existing IDs
-	existing := make(map[string]bool)
-	for _, w := range kiosk.windows {
-		if w.WindowID != "" {
-			existing[w.WindowID] = true
-		}
-	}
+	// Gather existing IDs on the same X display
+	existing := kiosk.existingWindowIDs(name)
 
 	// Find a unique ID that is not already in use
 	for _, id := range winIDs {
@@ -422,7 +647,7 @@ func (kiosk *Kiosk) getLatestWindowID(name string, searchName string) (string, e
 		searchName = "chromium"
 	}
 
-	out, err := exec.Command("xdotool", "search", "--onlyvisible", "--name", searchName).Output()
+	out, err := kiosk.displayCommand(context.Background(), name, "xdotool", "search", "--onlyvisible", "--name", searchName).Output()
 	if err != nil {
 		return "", fmt.Errorf("[%s] Could not find window: %w", name, err)
 	}
@@ -433,13 +658,8 @@ func (kiosk *Kiosk) getLatestWindowID(name string, searchName string) (string, e
 		return "", fmt.Errorf("[%s] No window found", name)
 	}
 
-	// Gather existing IDs
-	existing := make(map[string]bool)
-	for _, w := range kiosk.windows {
-		if w.WindowID != "" {
-			existing[w.WindowID] = true
-		}
-	}
+	// Gather existing IDs on the same X display
+	existing := kiosk.existingWindowIDs(name)
 
 	// Find a unique ID that is not already in use
 	for _, id := range winIDs {
@@ -453,68 +673,6 @@ func (kiosk *Kiosk) getLatestWindowID(name string, searchName string) (string, e
 	return "", fmt.Errorf("[%s] No unique window ID found", name)
 }
 
-func (kiosk *Kiosk) launchCustom(name string) {
-	kiosk.mu.Lock()
-	window, ok := kiosk.windows[name]
-	kiosk.mu.Unlock()
-
-	if !ok {
-		return
-	}
-
-	originalWinIDs, err := kiosk.xdotoolSearchVisible(window.Config.Exec.WindowSearch)
-	if err != nil {
-		log.Printf("[%s] Error searching for visible windows: %v", name, err)
-		return
-	}
-
-	log.Printf("[%s] Launching custom command: %s with args: %v", name, window.Config.Exec.Command, window.Config.Exec.Args)
-
-	cmd := exec.CommandContext(kiosk.ctx, window.Config.Exec.Command, window.Config.Exec.Args...)
-	cmd.Stdout = nil
-	err = cmd.Start()
-	if err != nil {
-		log.Printf("[%s] Error starting command: %v", name, err)
-		return
-	}
-
-	firstRun := true
-	for {
-		if !firstRun {
-			select {
-			case <-time.After(time.Second):
-			case <-kiosk.ctx.Done():
-				return
-			}
-		}
-		firstRun = false
-
-		winIDs, err := kiosk.xdotoolSearchVisible(window.Config.Exec.WindowSearch)
-		if err != nil {
-			log.Printf("[%s] Error searching for visible windows: %v", name, err)
-			continue
-		}
-
-		if len(winIDs) == 0 {
-			log.Printf("[%s] No visible windows found for %s", name, window.Config.Exec.WindowSearch)
-			continue
-		}
-
-		winID, err := kiosk.xdotoolFindLatestWindowID(name, originalWinIDs, winIDs)
-		if err != nil {
-			log.Printf("[%s] Error finding latest window ID: %v", name, err)
-			continue
-		}
-
-		window.WindowID = winID
-		break
-	}
-}
-
-func chromiumUserDataDir(name string) string {
-	return fmt.Sprintf("/tmp/.kiosk-chrome-user-data-%s", name)
-}
-
 func (kiosk *Kiosk) launchChrome(name string) {
 	kiosk.mu.Lock()
 	window, ok := kiosk.windows[name]
@@ -528,20 +686,34 @@ func (kiosk *Kiosk) launchChrome(name string) {
 	if port == 0 {
 		port = kiosk.cfg.DebugPort
 	}
-	userDir := chromiumUserDataDir(name)
-	os.RemoveAll(userDir)
-	os.MkdirAll(userDir, 0755)
+	browser, err := kiosk.browser()
+	if err != nil {
+		log.Fatalf("[%s] %v", name, err)
+	}
+
+	userDir, err := kiosk.prepareProfile(name)
+	if err != nil {
+		log.Fatalf("[%s] Failed to prepare browser profile: %v", name, err)
+	}
 
 	if !kiosk.portAvailable(port) {
 		log.Fatalf("[%s] Port %d in use", name, port)
 	}
 
-	url := window.Tabs[0].URL
+	windowSize := kiosk.cfg.NewWindowSize
+	if width, height, ok := kiosk.cfg.DisplayWindowSize(window.Config); ok {
+		windowSize = fmt.Sprintf("%d,%d", width, height)
+	}
+
+	// Tabs open blank and are navigated once their DevTools session is set up,
+	// so headers, cookies and injected scripts apply from the first load
 	args := []string{
 		fmt.Sprintf("--user-data-dir=%s", userDir),
-		fmt.Sprintf("--window-size=%s", kiosk.cfg.NewWindowSize),
 		fmt.Sprintf("--remote-debugging-port=%d", port),
 		fmt.Sprintf("--remote-allow-origins=http://localhost:%d", port),
+	}
+	args = append(args, kiosk.browserArgs([]string{
+		fmt.Sprintf("--window-size=%s", windowSize),
 		"--password-store=basic", // Use basic password store to disable GNOME Keyring prompts
 		"--disable-session-crashed-bubble",
 		"--disable-session-restore",
@@ -550,16 +722,15 @@ func (kiosk *Kiosk) launchChrome(name string) {
 		"--no-first-run",
 		"--disable-extensions",
 		"--new-window",
-		url,
-	}
+	}, "about:blank")...)
 
-	originalWinIDs, err := kiosk.xdotoolSearchVisible("chromium")
+	originalWinIDs, err := kiosk.xdotoolSearchVisible(name, browser.WindowSearch)
 	if err != nil {
 		log.Printf("[%s] Error searching for visible windows: %v", name, err)
 		return
 	}
 
-	cmd := exec.CommandContext(kiosk.ctx, "chromium", args...)
+	cmd := kiosk.displayCommand(kiosk.ctx, name, browser.Binary, args...)
 	cmd.Stderr = nil
 	_ = cmd.Start()
 
@@ -579,14 +750,14 @@ func (kiosk *Kiosk) launchChrome(name string) {
 			firstRun = false
 		}
 
-		winIDs, err := kiosk.xdotoolSearchVisible("chromium")
+		winIDs, err := kiosk.xdotoolSearchVisible(name, browser.WindowSearch)
 		if err != nil {
 			log.Printf("[%s] Error searching for visible windows: %v", name, err)
 			continue
 		}
 
 		if len(winIDs) == 0 {
-			log.Printf("[%s] No visible windows found for %s", name, "chromium")
+			log.Printf("[%s] No visible windows found for %s", name, browser.WindowSearch)
 			continue
 		}
 
@@ -609,19 +780,23 @@ func (kiosk *Kiosk) launchChrome(name string) {
 	for i := 1; i < len(window.Tabs); i++ {
 		tab := window.Tabs[i]
 
-		args = []string{
-			"--new-tab",
-			tab.URL,
-			fmt.Sprintf("--user-data-dir=%s", userDir),
+		target, err := kiosk.createChromeTab(port, "about:blank")
+		if err != nil {
+			log.Fatalf("[%s] Failed to create tab %d: %v", name, i, err)
 		}
 
-		cmd = exec.CommandContext(kiosk.ctx, "chromium", args...)
-		cmd.Stderr = nil
-		_ = cmd.Start()
+		tab.ID = target.ID
+		tab.WSURL = target.WebSocketDebuggerURL
+		log.Printf("[%s] Tab: %s (ID: %s, WS: %s)\n", name, tab.URL, tab.ID, tab.WSURL)
+	}
 
-		err = kiosk.waitForTabID(name, port, window, i)
-		if err != nil {
-			log.Fatalf("[%s] Failed to wait for tab ID %d: %v", name, i, err)
+	for _, tab := range window.Tabs {
+		if _, err := kiosk.tabSession(name, tab); err != nil {
+			log.Printf("[%s] Error connecting to tab %s: %v", name, tab.URL, err)
+		}
+
+		if err := kiosk.navigateChromeTab(name, tab); err != nil {
+			log.Printf("[%s] Error loading tab %s: %v", name, tab.URL, err)
 		}
 	}
 }
@@ -644,6 +819,10 @@ func (kiosk *Kiosk) waitForTabID(name string, port int, window *DisplayState, ta
 		// Store state
 		for _, chromeTab := range chromeTabs {
 			exists := false
+			if targetType, ok := chromeTab["type"].(string); ok && targetType != "page" {
+				continue
+			}
+
 			id, ok := chromeTab["id"].(string)
 			if !ok {
 				continue
@@ -706,65 +885,83 @@ func (kiosk *Kiosk) fetchTabs(port int) ([]map[string]interface{}, error) {
 	return tabs, nil
 }
 
-func (kiosk *Kiosk) moveWindow(name string) {
+// windowID returns the X window ID of a display, read under the lock since
+// the window is looked up again whenever the display is restarted.
+func (kiosk *Kiosk) windowID(name string) (string, bool) {
+	kiosk.mu.Lock()
+	defer kiosk.mu.Unlock()
+
 	window, ok := kiosk.windows[name]
 	if !ok {
 		log.Printf("[%s] No window state found for %s", name, name)
+		return "", false
+	}
+	if window.WindowID == "" {
+		log.Printf("[%s] No window found yet for %s", name, name)
+		return "", false
+	}
+
+	return window.WindowID, true
+}
+
+func (kiosk *Kiosk) moveWindow(name string) {
+	windowID, ok := kiosk.windowID(name)
+	if !ok {
 		return
 	}
 
-	log.Printf("[%s] Activating window %s\n", name, window.WindowID)
-	err := exec.CommandContext(kiosk.ctx, "xdotool", "windowactivate", window.WindowID).Run()
+	kiosk.mu.Lock()
+	x, y := strconv.Itoa(kiosk.windows[name].Config.X), strconv.Itoa(kiosk.windows[name].Config.Y)
+	kiosk.mu.Unlock()
+
+	log.Printf("[%s] Activating window %s\n", name, windowID)
+	err := kiosk.displayCommand(kiosk.ctx, name, "xdotool", "windowactivate", windowID).Run()
 	if err != nil {
-		log.Printf("[%s] Error activating window %s: %v", name, window.WindowID, err)
+		log.Printf("[%s] Error activating window %s: %v", name, windowID, err)
 	}
 
-	x, y := strconv.Itoa(window.Config.X), strconv.Itoa(window.Config.Y)
-
-	log.Printf("[%s] Moving window %s to %s:%s\n", name, window.WindowID, x, y)
-	err = exec.CommandContext(kiosk.ctx, "xdotool", "windowmove", window.WindowID, x, y).Run()
+	log.Printf("[%s] Moving window %s to %s:%s\n", name, windowID, x, y)
+	err = kiosk.displayCommand(kiosk.ctx, name, "xdotool", "windowmove", windowID, x, y).Run()
 	if err != nil {
-		log.Printf("[%s] Error moving window %s: %v", name, window.WindowID, err)
+		log.Printf("[%s] Error moving window %s: %v", name, windowID, err)
 	}
 }
 
 func (kiosk *Kiosk) SendKeyToWindow(name string, key string, delayBeforeSending time.Duration) {
-	window, ok := kiosk.windows[name]
-	if !ok {
-		log.Printf("[%s] No window state found for %s", name, name)
-		return
-	}
-
 	select {
 	case <-time.After(delayBeforeSending):
 	case <-kiosk.ctx.Done():
 		return
 	}
 
-	log.Printf("[%s] Activating window %s\n", name, window.WindowID)
-	err := exec.CommandContext(kiosk.ctx, "xdotool", "windowactivate", window.WindowID).Run()
+	windowID, ok := kiosk.windowID(name)
+	if !ok {
+		return
+	}
+
+	log.Printf("[%s] Activating window %s\n", name, windowID)
+	err := kiosk.displayCommand(kiosk.ctx, name, "xdotool", "windowactivate", windowID).Run()
 	if err != nil {
-		log.Printf("[%s] Error activating window %s: %v", name, window.WindowID, err)
+		log.Printf("[%s] Error activating window %s: %v", name, windowID, err)
 	}
 
-	log.Printf("[%s] Sending %s to window %s\n", name, key, window.WindowID)
-	err = exec.CommandContext(kiosk.ctx, "xdotool", "key", "--window", window.WindowID, key).Run()
+	log.Printf("[%s] Sending %s to window %s\n", name, key, windowID)
+	err = kiosk.displayCommand(kiosk.ctx, name, "xdotool", "key", "--window", windowID, key).Run()
 	if err != nil {
-		log.Printf("[%s] Error sending %s to window %s: %v", name, key, window.WindowID, err)
+		log.Printf("[%s] Error sending %s to window %s: %v", name, key, windowID, err)
 	}
 }
 
 func (kiosk *Kiosk) CloseWindow(name string) {
-	window, ok := kiosk.windows[name]
+	windowID, ok := kiosk.windowID(name)
 	if !ok {
-		log.Printf("[%s] No window state found for %s", name, name)
 		return
 	}
 
-	log.Printf("[%s] Closing window %s\n", name, window.WindowID)
-	err := exec.Command("xdotool", "windowclose", window.WindowID).Run()
+	log.Printf("[%s] Closing window %s\n", name, windowID)
+	err := kiosk.displayCommand(context.Background(), name, "xdotool", "windowclose", windowID).Run()
 	if err != nil {
-		log.Printf("[%s] Error closing window %s: %v", name, window.WindowID, err)
+		log.Printf("[%s] Error closing window %s: %v", name, windowID, err)
 	}
 }
 
@@ -784,42 +981,34 @@ func (kiosk *Kiosk) portAvailable(port int) bool {
 
 func (kiosk *Kiosk) refreshTabAndWait(tab *TabState, name string) (bool, error) {
 	log.Printf("[%s] Refreshing tab %s\n", name, tab.URL)
-	err := kiosk.navigateChromeTab(*tab)
+	tab.refreshMu.Lock()
+	defer tab.refreshMu.Unlock()
+
+	err := kiosk.loadAndWait(name, tab)
 	if err != nil {
 		log.Printf("[%s] Error refreshing tab %s: %v", name, tab.ID, err)
 		return false, err
 	}
 
-	if tab.DelayAfterRefresh > 0 {
+	if delay := refreshDelay(tab); delay > 0 {
 		select {
-		case <-time.After(time.Duration(tab.DelayAfterRefresh) * time.Second):
+		case <-time.After(delay):
 		case <-kiosk.ctx.Done():
 			return false, kiosk.ctx.Err()
 		}
 	}
 
-	tab.LastRefresh = time.Now().Unix()
-	log.Printf("[%s] Tab %s refreshed successfully", name, tab.URL)
-	return true, nil
-}
+	if session, err := kiosk.tabSession(name, tab); err == nil {
+		kiosk.applyInjections(name, tab, session)
+		kiosk.applyView(name, tab, session)
+	}
 
-func (kiosk *Kiosk) execCycle(name string) {
 	kiosk.mu.Lock()
-	_, ok := kiosk.windows[name]
+	tab.LastRefresh = time.Now().Unix()
 	kiosk.mu.Unlock()
 
-	if !ok {
-		return
-	}
-
-	kiosk.wg.Add(1)
-	go func() {
-		defer func() {
-			kiosk.wg.Done()
-		}()
-
-		<-kiosk.ctx.Done()
-	}()
+	log.Printf("[%s] Tab %s refreshed successfully", name, tab.URL)
+	return true, nil
 }
 
 func (kiosk *Kiosk) tabCycler(name string) {
@@ -834,18 +1023,34 @@ func (kiosk *Kiosk) tabCycler(name string) {
 	kiosk.wg.Add(1)
 	go func() {
 		defer func() {
-			userDir := chromiumUserDataDir(name)
-			os.RemoveAll(userDir)
+			kiosk.cleanupProfile(name)
 			kiosk.wg.Done()
 		}()
 
+		var previous *TabState
+
 		for {
-			for _, tab := range display.Tabs {
+			shown := 0
+
+			for i, tab := range display.Tabs {
 				dwell := time.Duration(tab.DwellTime) * time.Second
+				preloaded := kiosk.awaitPreload(name, tab)
 
-				refreshed := false
+				show, healthy := kiosk.tabUsable(name, tab)
+				if !show {
+					log.Printf("[%s] Skipping broken tab %s", name, tab.URL)
+					continue
+				}
+				shown++
+
+				// Broken tabs show their fallback page until their next retry
+				refreshed := !healthy || preloaded
+
+				kiosk.mu.Lock()
+				due := refreshIntervalDue(tab)
+				kiosk.mu.Unlock()
 
-				if tab.RefreshInterval > 0 && time.Since(time.Unix(tab.LastRefresh, 0)) > time.Duration(tab.RefreshInterval)*time.Second {
+				if !refreshed && due {
 					refreshed, _ = kiosk.refreshTabAndWait(tab, name)
 				}
 
@@ -854,17 +1059,47 @@ func (kiosk *Kiosk) tabCycler(name string) {
 				}
 
 				log.Printf("[%s] Activating tab %s for %v seconds", name, tab.URL, dwell.Seconds())
-				err := kiosk.activateChromeTab(display.DebugPort, tab.ID)
+				err := kiosk.switchTab(name, display, previous, tab)
 				if err != nil {
 					log.Printf("[%s] Error activating tab %s: %v", name, tab.ID, err)
 				}
+				previous = tab
+
+				kiosk.mu.Lock()
+				display.ActiveTab = i
+				kiosk.mu.Unlock()
 
 				if !refreshed && tab.RefreshAfterLoad {
 					kiosk.refreshTabAndWait(tab, name)
 				}
 
+				if healthy {
+					kiosk.activateActions(name, tab)
+					dwell = kiosk.startAutoScroll(name, tab, dwell)
+				}
+
+				kiosk.preloadNext(name, display, i)
+
+				reset, err := kiosk.waitDwell(display, dwell)
+				if err != nil {
+					return
+				}
+
+				if healthy {
+					kiosk.stopAutoScroll(name, tab)
+				}
+
+				// Start over from the first tab after an interactive session
+				if reset {
+					kiosk.resetInteractive(name, display)
+					break
+				}
+			}
+
+			// Every tab is broken, wait for one to be due for a retry
+			if shown == 0 {
 				select {
-				case <-time.After(dwell):
+				case <-time.After(time.Second):
 				case <-kiosk.ctx.Done():
 					return
 				}
@@ -878,39 +1113,79 @@ func (kiosk *Kiosk) activateChromeTab(port int, tabID string) error {
 	return err
 }
 
-func (kiosk *Kiosk) refreshChromeTab(tab TabState) error {
-	requestID := kiosk.requestID.Next()
+type ChromeTarget struct {
+	ID                   string `json:"id"`
+	Type                 string `json:"type"`
+	URL                  string `json:"url"`
+	WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+}
+
+// createChromeTab opens a new tab through the DevTools HTTP endpoint and
+// returns its target.
+func (kiosk *Kiosk) createChromeTab(port int, tabURL string) (ChromeTarget, error) {
+	var target ChromeTarget
 
-	req := map[string]interface{}{
-		"id":     requestID,
-		"method": "Page.reload",
-		"params": map[string]interface{}{"ignoreCache": true},
+	req, err := http.NewRequestWithContext(kiosk.ctx, http.MethodPut, fmt.Sprintf("http://localhost:%d/json/new?%s", port, url.QueryEscape(tabURL)), nil)
+	if err != nil {
+		return target, err
 	}
-	return kiosk.chromeWebsocketSend(tab, req)
-}
 
-func (kiosk *Kiosk) navigateChromeTab(tab TabState) error {
-	requestID := kiosk.requestID.Next()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return target, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return target, fmt.Errorf("unexpected status creating tab: %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&target); err != nil {
+		return target, err
+	}
 
-	req := map[string]interface{}{
-		"id":     requestID,
-		"method": "Page.navigate",
-		"params": map[string]interface{}{"url": tab.URL, "ignoreCache": true},
+	if target.ID == "" || target.WebSocketDebuggerURL == "" {
+		return target, errors.New("incomplete target returned when creating tab")
 	}
-	return kiosk.chromeWebsocketSend(tab, req)
+
+	return target, nil
+}
+
+func (kiosk *Kiosk) refreshChromeTab(name string, tab *TabState) error {
+	return kiosk.chromeWebsocketSend(name, tab, "Page.reload", map[string]interface{}{"ignoreCache": true})
 }
 
-func (kiosk *Kiosk) chromeWebsocketSend(tab TabState, req map[string]interface{}) error {
-	if tab.WSURL == "" {
-		return errors.New("no websocket url")
+func (kiosk *Kiosk) navigateChromeTab(name string, tab *TabState) error {
+	session, err := kiosk.tabSession(name, tab)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		ErrorText string `json:"errorText"`
+	}
+	err = session.Call("Page.navigate", map[string]interface{}{"url": kiosk.pageURL(name, tab), "ignoreCache": true}, &result)
+	if err != nil {
+		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	c, _, err := websocket.Dial(ctx, tab.WSURL, nil)
+
+	if result.ErrorText != "" && !isAbortError(result.ErrorText) {
+		kiosk.markTabFailed(name, tab, result.ErrorText)
+		return errors.New(result.ErrorText)
+	}
+
+	kiosk.mu.Lock()
+	tab.needsNavigate = false
+	kiosk.mu.Unlock()
+
+	return nil
+}
+
+func (kiosk *Kiosk) chromeWebsocketSend(name string, tab *TabState, method string, params map[string]interface{}) error {
+	session, err := kiosk.tabSession(name, tab)
 	if err != nil {
 		return err
 	}
-	defer c.Close(websocket.StatusNormalClosure, "bye")
 
-	return wsjson.Write(ctx, c, req)
+	return session.Call(method, params, nil)
 }
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// layoutDwellTime keeps the cycler of a layout display idle, the generated
+// page rotates and refreshes its regions by itself.
+const layoutDwellTime = 60
+
+// layoutURL is the generated page the web UI serves for a display's layout.
+func (kiosk *Kiosk) layoutURL(name string) string {
+	return fmt.Sprintf("http://localhost:%d/layout?display=%s", kiosk.webPort, url.QueryEscape(name))
+}
+
+// hasTabs reports whether a browser should be started for the display, either
+// for its configured tabs or for its layout page.
+func (kiosk *Kiosk) hasTabs(name string) bool {
+	kiosk.mu.Lock()
+	defer kiosk.mu.Unlock()
+
+	ds, ok := kiosk.windows[name]
+	return ok && len(ds.Tabs) > 0
+}
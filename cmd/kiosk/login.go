@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"time"
+
+	"kiosk/internal/config"
+)
+
+const defaultLoginTimeout = 30 * time.Second
+
+// secret resolves a named secret from the secrets file, falling back to an
+// environment variable of the same name.
+func (kiosk *Kiosk) secret(name string) (string, bool) {
+	kiosk.mu.Lock()
+	value, ok := kiosk.secrets[name]
+	kiosk.mu.Unlock()
+
+	if ok {
+		return value, true
+	}
+
+	return os.LookupEnv(name)
+}
+
+// registerLogin runs the tab's login steps whenever a page finishes loading
+// on its login URL.
+func (kiosk *Kiosk) registerLogin(name string, tab *TabState, session *CDPSession) {
+	if tab.Login == nil || tab.Login.URL == "" || len(tab.Login.Steps) == 0 {
+		return
+	}
+
+	re, err := regexp.Compile(tab.Login.URL)
+	if err != nil {
+		log.Printf("[%s] Invalid login URL pattern %q for tab %s: %v", name, tab.Login.URL, tab.URL, err)
+		return
+	}
+
+	session.On("Page.loadEventFired", func(json.RawMessage) {
+		go kiosk.loginIfNeeded(name, tab, session, re)
+	})
+
+	go kiosk.loginIfNeeded(name, tab, session, re)
+}
+
+func (kiosk *Kiosk) loginIfNeeded(name string, tab *TabState, session *CDPSession, re *regexp.Regexp) {
+	if !tab.loginMu.TryLock() {
+		return
+	}
+	defer tab.loginMu.Unlock()
+
+	var href string
+	if err := session.Evaluate("location.href", &href); err != nil {
+		return
+	}
+
+	if !re.MatchString(href) {
+		return
+	}
+
+	log.Printf("[%s] Tab %s landed on login page %s, logging in", name, tab.URL, href)
+	if err := kiosk.runLogin(name, tab.Login, session); err != nil {
+		log.Printf("[%s] Login failed for tab %s: %v", name, tab.URL, err)
+		return
+	}
+
+	log.Printf("[%s] Logged in to tab %s", name, tab.URL)
+}
+
+func (kiosk *Kiosk) runLogin(name string, login *config.LoginConfig, session *CDPSession) error {
+	timeout := defaultLoginTimeout
+	if login.Timeout > 0 {
+		timeout = time.Duration(login.Timeout) * time.Second
+	}
+
+	for i, step := range login.Steps {
+		var err error
+
+		switch {
+		case step.Fill != "":
+			value := step.Value
+			if step.Secret != "" {
+				secret, ok := kiosk.secret(step.Secret)
+				if !ok {
+					return fmt.Errorf("step %d: secret %s not found", i, step.Secret)
+				}
+				value = secret
+			}
+
+			err = kiosk.waitForSelector(session, step.Fill, timeout)
+			if err == nil {
+				err = evaluateWithArgs(session, fillScript, step.Fill, value)
+			}
+		case step.Click != "":
+			err = kiosk.waitForSelector(session, step.Click, timeout)
+			if err == nil {
+				err = evaluateWithArgs(session, clickScript, step.Click)
+			}
+		case step.WaitForURL != "":
+			err = kiosk.waitForURL(session, step.WaitForURL, timeout)
+		case step.Wait > 0:
+			select {
+			case <-time.After(time.Duration(step.Wait) * time.Millisecond):
+			case <-session.Done():
+				err = session.ctx.Err()
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("step %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+const fillScript = `(function (selector, value) {
+  var el = document.querySelector(selector);
+  if (!el) throw new Error("element not found: " + selector);
+  el.focus();
+  var desc = Object.getOwnPropertyDescriptor(Object.getPrototypeOf(el), "value");
+  if (desc && desc.set) desc.set.call(el, value);
+  else el.value = value;
+  el.dispatchEvent(new Event("input", { bubbles: true }));
+  el.dispatchEvent(new Event("change", { bubbles: true }));
+})`
+
+const clickScript = `(function (selector) {
+  var el = document.querySelector(selector);
+  if (!el) throw new Error("element not found: " + selector);
+  el.click();
+})`
+
+// evaluateWithArgs calls a JavaScript function expression with JSON encoded
+// arguments, so values never need escaping by hand.
+func evaluateWithArgs(session *CDPSession, fn string, args ...interface{}) error {
+	encoded := make([]byte, 0)
+	for i, arg := range args {
+		data, err := json.Marshal(arg)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			encoded = append(encoded, ',')
+		}
+		encoded = append(encoded, data...)
+	}
+
+	return session.Evaluate(fmt.Sprintf("%s(%s)", fn, encoded), nil)
+}
+
+// poll evaluates an expression until it returns true or the timeout expires.
+func poll(session *CDPSession, expression string, timeout time.Duration) error {
+	return pollUntil(session, expression, timeout, func() bool {
+		var ok bool
+		return session.Evaluate(expression, &ok) == nil && ok
+	})
+}
+
+// pollUntil calls check until it returns true or the timeout expires.
+func pollUntil(session *CDPSession, what string, timeout time.Duration, check func() bool) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		if check() {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %v waiting for %s", timeout, what)
+		}
+
+		select {
+		case <-time.After(250 * time.Millisecond):
+		case <-session.Done():
+			return session.ctx.Err()
+		}
+	}
+}
+
+func (kiosk *Kiosk) waitForSelector(session *CDPSession, selector string, timeout time.Duration) error {
+	encoded, _ := json.Marshal(selector)
+	return poll(session, fmt.Sprintf("document.querySelector(%s) !== null", encoded), timeout)
+}
+
+func (kiosk *Kiosk) waitForURL(session *CDPSession, pattern string, timeout time.Duration) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid URL pattern %q: %w", pattern, err)
+	}
+
+	// Matched here rather than in the page, so the pattern is the same Go
+	// regular expression syntax as the other URL patterns
+	return pollUntil(session, fmt.Sprintf("URL matching %s", pattern), timeout, func() bool {
+		var href string
+		return session.Evaluate("location.href", &href) == nil && re.MatchString(href)
+	})
+}
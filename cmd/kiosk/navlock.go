@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"kiosk/internal/config"
+)
+
+// navigationAllowlist compiles the allowed URL patterns of a navigation lock,
+// defaulting to the origin of the tab's page.
+func navigationAllowlist(pageURL string, allow []string) ([]*regexp.Regexp, error) {
+	if len(allow) == 0 {
+		u, err := url.Parse(pageURL)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("can't derive an allowed origin from %s", pageURL)
+		}
+
+		allow = []string{"^" + regexp.QuoteMeta(u.Scheme+"://"+u.Host) + "(/|$)"}
+	}
+
+	patterns := make([]*regexp.Regexp, 0, len(allow))
+	for _, pattern := range allow {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allow pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, re)
+	}
+
+	return patterns, nil
+}
+
+// A disallowed navigation to the same origin this soon after reverting one to
+// the tab's URL, without input in between, is taken as the tab's URL
+// redirecting there
+const redirectLoopWindow = 10 * time.Second
+
+func sameURL(a, b string) bool {
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
+
+// registerNavigationLock reverts navigations of the tab's main frame that
+// leave its allowed URLs, and optionally returns the tab to its URL after it
+// has been elsewhere for a while.
+func (kiosk *Kiosk) registerNavigationLock(name string, tab *TabState, session *CDPSession) error {
+	lock := tab.NavigationLock
+	if lock == nil {
+		return nil
+	}
+
+	home := kiosk.pageURL(name, tab)
+	allow, err := navigationAllowlist(home, lock.Allow)
+	if err != nil {
+		return err
+	}
+
+	var loginURL *regexp.Regexp
+	if tab.Login != nil && tab.Login.URL != "" {
+		loginURL, _ = regexp.Compile(tab.Login.URL)
+	}
+
+	var mu sync.Mutex
+	lastAllowed := home
+	var returnTimer *time.Timer
+	returnGeneration := 0
+
+	// The last reverted origin, to tell a redirect loop from someone
+	// following links
+	var revertedFrom string
+	var revertedAt time.Time
+	var revertedHome bool
+
+	allowed := func(u string) bool {
+		// Internal pages and the fallback page are never reverted
+		if u == "about:blank" || strings.HasPrefix(u, "data:") || strings.HasPrefix(u, "chrome-error:") {
+			return true
+		}
+
+		kiosk.mu.Lock()
+		showingFallback := tab.ShowingFallback
+		kiosk.mu.Unlock()
+
+		if showingFallback || sameURL(u, home) || (loginURL != nil && loginURL.MatchString(u)) {
+			return true
+		}
+
+		for _, re := range allow {
+			if re.MatchString(u) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	returnAfter := time.Duration(lock.ReturnAfter) * time.Second
+
+	// returnHome sends the tab back to its URL once nobody used it for the
+	// return time. Input meanwhile postpones the return.
+	var returnHome func(generation int)
+	returnHome = func(generation int) {
+		kiosk.mu.Lock()
+		display, ok := kiosk.windows[name]
+		interacting := ok && display.Interacting
+		idle := time.Since(tab.lastInput)
+		kiosk.mu.Unlock()
+
+		if interacting || idle < returnAfter {
+			wait := returnAfter - idle
+			if interacting || wait <= 0 {
+				wait = returnAfter
+			}
+
+			mu.Lock()
+			if generation == returnGeneration {
+				returnTimer = time.AfterFunc(wait, func() { returnHome(generation) })
+			}
+			mu.Unlock()
+			return
+		}
+
+		// The tab navigated again meanwhile, which armed a new timer
+		mu.Lock()
+		current := generation == returnGeneration
+		u := lastAllowed
+		if current {
+			returnTimer = nil
+		}
+		mu.Unlock()
+
+		if !current {
+			return
+		}
+
+		log.Printf("[%s] Tab %s has been idle on %s for %ds, returning to its URL", name, tab.URL, u, lock.ReturnAfter)
+		if err := kiosk.navigateChromeTab(name, tab); err != nil {
+			log.Printf("[%s] Error returning tab %s to its URL: %v", name, tab.URL, err)
+		}
+	}
+
+	navigated := func(u string) {
+		if !allowed(u) {
+			origin := urlOrigin(u)
+
+			kiosk.mu.Lock()
+			lastInput := tab.lastInput
+			kiosk.mu.Unlock()
+
+			// Only a loop if the last revert went back to the tab's URL and
+			// nobody used the tab since, otherwise it's someone trying the
+			// same link again
+			mu.Lock()
+			looping := origin != "" && origin == revertedFrom && revertedHome &&
+				time.Since(revertedAt) < redirectLoopWindow && !lastInput.After(revertedAt)
+			target := home
+			if lock.Action != config.NavigationHome {
+				target = lastAllowed
+			}
+			if looping {
+				revertedFrom = ""
+			} else {
+				revertedFrom, revertedAt, revertedHome = origin, time.Now(), sameURL(target, home)
+			}
+			mu.Unlock()
+
+			if looping {
+				log.Printf("[%s] Tab %s keeps getting redirected to %s, which is not allowed (add it to allow or configure login)", name, tab.URL, origin)
+				go kiosk.markTabFailed(name, tab, fmt.Sprintf("redirect loop to %s", origin))
+				return
+			}
+
+			log.Printf("[%s] Tab %s navigated to %s, which is not allowed, returning to %s", name, tab.URL, u, target)
+			go func() {
+				if err := session.Call("Page.navigate", map[string]interface{}{"url": target}, nil); err != nil {
+					log.Printf("[%s] Error returning tab %s to %s: %v", name, tab.URL, target, err)
+				}
+			}()
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		lastAllowed = u
+
+		if lock.ReturnAfter <= 0 {
+			return
+		}
+
+		if returnTimer != nil {
+			returnTimer.Stop()
+			returnTimer = nil
+		}
+		returnGeneration++
+
+		if sameURL(u, home) {
+			return
+		}
+
+		generation := returnGeneration
+		returnTimer = time.AfterFunc(returnAfter, func() { returnHome(generation) })
+	}
+
+	session.On("Page.frameNavigated", func(params json.RawMessage) {
+		var event struct {
+			Frame struct {
+				ID          string `json:"id"`
+				ParentID    string `json:"parentId"`
+				URL         string `json:"url"`
+				URLFragment string `json:"urlFragment"`
+			} `json:"frame"`
+		}
+		if err := json.Unmarshal(params, &event); err != nil || event.Frame.ParentID != "" {
+			return
+		}
+
+		navigated(event.Frame.URL + event.Frame.URLFragment)
+	})
+
+	// Hash and history API navigations of single page apps
+	session.On("Page.navigatedWithinDocument", func(params json.RawMessage) {
+		var event struct {
+			FrameID string `json:"frameId"`
+			URL     string `json:"url"`
+		}
+		if err := json.Unmarshal(params, &event); err != nil || event.FrameID != tab.ID {
+			return
+		}
+
+		navigated(event.URL)
+	})
+
+	go func() {
+		<-session.Done()
+
+		mu.Lock()
+		if returnTimer != nil {
+			returnTimer.Stop()
+		}
+		mu.Unlock()
+	}()
+
+	return nil
+}
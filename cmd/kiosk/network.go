@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// secretOrValue returns the named secret if one is given, otherwise the
+// literal value.
+func (kiosk *Kiosk) secretOrValue(value string, secretName string) (string, error) {
+	if secretName == "" {
+		return value, nil
+	}
+
+	secret, ok := kiosk.secret(secretName)
+	if !ok {
+		return "", fmt.Errorf("secret %s not found", secretName)
+	}
+
+	return secret, nil
+}
+
+// registerNetwork applies the tab's extra headers and cookies, and answers
+// basic-auth challenges with its configured credentials. Each part is applied
+// on its own, so one failing (e.g. a missing secret) doesn't keep the others
+// or the tab itself from loading.
+func (kiosk *Kiosk) registerNetwork(name string, tab *TabState, session *CDPSession) error {
+	if len(tab.Headers) == 0 && len(tab.Cookies) == 0 && tab.BasicAuth == nil {
+		return nil
+	}
+
+	if err := session.Call("Network.enable", nil, nil); err != nil {
+		return err
+	}
+
+	var errs []error
+
+	headers := make(map[string]string)
+	for _, h := range tab.Headers {
+		value, err := kiosk.secretOrValue(h.Value, h.Secret)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("header %s: %w", h.Name, err))
+			continue
+		}
+		headers[h.Name] = value
+	}
+
+	if err := kiosk.setCookies(tab, session); err != nil {
+		errs = append(errs, err)
+	}
+
+	var auth *basicAuth
+	if tab.BasicAuth != nil {
+		var err error
+		if auth, err = kiosk.basicAuthCredentials(tab); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(headers) > 0 || auth != nil {
+		if err := kiosk.registerFetch(name, tab, session, headers, auth); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	log.Printf("[%s] Applied %d headers and %d cookies to tab %s", name, len(headers), len(tab.Cookies), tab.URL)
+	return errors.Join(errs...)
+}
+
+// setCookies sets the tab's configured cookies in the browser.
+func (kiosk *Kiosk) setCookies(tab *TabState, session *CDPSession) error {
+	if len(tab.Cookies) == 0 {
+		return nil
+	}
+
+	tabURL, err := url.Parse(tab.URL)
+	if err != nil {
+		return fmt.Errorf("invalid tab URL %s: %w", tab.URL, err)
+	}
+
+	cookies := []map[string]interface{}{}
+	for _, c := range tab.Cookies {
+		value, err := kiosk.secretOrValue(c.Value, c.Secret)
+		if err != nil {
+			return fmt.Errorf("cookie %s: %w", c.Name, err)
+		}
+
+		domain := c.Domain
+		if domain == "" {
+			domain = tabURL.Hostname()
+		}
+
+		path := c.Path
+		if path == "" {
+			path = "/"
+		}
+
+		cookies = append(cookies, map[string]interface{}{
+			"name":     c.Name,
+			"value":    value,
+			"domain":   domain,
+			"path":     path,
+			"secure":   c.Secure,
+			"httpOnly": c.HTTPOnly,
+		})
+	}
+
+	return session.Call("Network.setCookies", map[string]interface{}{"cookies": cookies}, nil)
+}
+
+type basicAuth struct {
+	username string
+	password string
+}
+
+func (kiosk *Kiosk) basicAuthCredentials(tab *TabState) (*basicAuth, error) {
+	username, err := kiosk.secretOrValue(tab.BasicAuth.Username, tab.BasicAuth.UsernameSecret)
+	if err != nil {
+		return nil, fmt.Errorf("basic auth username: %w", err)
+	}
+
+	password, err := kiosk.secretOrValue("", tab.BasicAuth.PasswordSecret)
+	if err != nil {
+		return nil, fmt.Errorf("basic auth password: %w", err)
+	}
+
+	return &basicAuth{username: username, password: password}, nil
+}
+
+func urlOrigin(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// registerFetch intercepts the tab's requests to add its extra headers and to
+// answer basic-auth challenges. Headers are only added to requests for the
+// origin of the tab's URL, so credentials don't leak to third-party hosts the
+// page loads from.
+func (kiosk *Kiosk) registerFetch(name string, tab *TabState, session *CDPSession, headers map[string]string, auth *basicAuth) error {
+	origin := urlOrigin(kiosk.pageURL(name, tab))
+	if origin == "" && auth == nil {
+		return fmt.Errorf("can't derive the origin of %s to send headers to", tab.URL)
+	}
+
+	// Every paused request must be let through again
+	session.On("Fetch.requestPaused", func(params json.RawMessage) {
+		var event struct {
+			RequestID string `json:"requestId"`
+			Request   struct {
+				URL     string            `json:"url"`
+				Headers map[string]string `json:"headers"`
+			} `json:"request"`
+		}
+		if err := json.Unmarshal(params, &event); err != nil {
+			return
+		}
+
+		continueParams := map[string]interface{}{"requestId": event.RequestID}
+		if len(headers) > 0 && origin != "" && urlOrigin(event.Request.URL) == origin {
+			merged := []map[string]string{}
+			for k, v := range event.Request.Headers {
+				if _, ok := headerValue(headers, k); !ok {
+					merged = append(merged, map[string]string{"name": k, "value": v})
+				}
+			}
+			for k, v := range headers {
+				merged = append(merged, map[string]string{"name": k, "value": v})
+			}
+			continueParams["headers"] = merged
+		}
+
+		go session.Call("Fetch.continueRequest", continueParams, nil)
+	})
+
+	if auth != nil {
+		session.On("Fetch.authRequired", func(params json.RawMessage) {
+			var event struct {
+				RequestID     string `json:"requestId"`
+				AuthChallenge struct {
+					Origin string `json:"origin"`
+					Realm  string `json:"realm"`
+				} `json:"authChallenge"`
+			}
+			if err := json.Unmarshal(params, &event); err != nil {
+				return
+			}
+
+			log.Printf("[%s] Answering basic auth challenge from %s (%s)", name, event.AuthChallenge.Origin, event.AuthChallenge.Realm)
+			go session.Call("Fetch.continueWithAuth", map[string]interface{}{
+				"requestId": event.RequestID,
+				"authChallengeResponse": map[string]interface{}{
+					"response": "ProvideCredentials",
+					"username": auth.username,
+					"password": auth.password,
+				},
+			}, nil)
+		})
+	}
+
+	// Without basic auth only requests to the tab's origin need pausing
+	pattern := "*"
+	if auth == nil {
+		pattern = origin + "/*"
+	}
+
+	return session.Call("Fetch.enable", map[string]interface{}{
+		"handleAuthRequests": auth != nil,
+		"patterns":           []map[string]interface{}{{"urlPattern": pattern}},
+	}, nil)
+}
+
+// headerValue looks up a header case-insensitively.
+func headerValue(headers map[string]string, name string) (string, bool) {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return "", false
+}
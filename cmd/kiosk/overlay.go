@@ -0,0 +1,385 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kiosk/internal/config"
+)
+
+const (
+	defaultOverlayOpacity = 0.8
+	defaultClockFormat    = "HH:mm"
+	defaultTickerSpeed    = 80
+	defaultTickerRefresh  = 300
+)
+
+// overlayScript draws the overlay bar in a shadow root so the page's styles
+// can't affect it, and exposes setTicker to update the text in place.
+const overlayScript = `(function (opts) {
+  if (window !== window.top || window.__kioskOverlay) return;
+
+  var state = { ticker: opts.ticker };
+  window.__kioskOverlay = {
+    setTicker: function (text) {
+      state.ticker = text;
+      if (state.update) state.update();
+    },
+  };
+
+  function pad(n) { return (n < 10 ? "0" : "") + n; }
+  function formatTime(d, format) {
+    var h = d.getHours();
+    return format
+      .replace("YYYY", d.getFullYear())
+      .replace("MM", pad(d.getMonth() + 1))
+      .replace("DD", pad(d.getDate()))
+      .replace("HH", pad(h))
+      .replace("hh", pad(h % 12 || 12))
+      .replace("mm", pad(d.getMinutes()))
+      .replace("ss", pad(d.getSeconds()))
+      .replace("A", h < 12 ? "AM" : "PM");
+  }
+
+  function build() {
+    var host = document.createElement("div");
+    host.setAttribute("data-kiosk", "overlay");
+    host.style.cssText = "position:fixed;left:0;right:0;" + opts.position + ":0;z-index:2147483647;pointer-events:none;";
+    var root = host.attachShadow({ mode: "closed" });
+    root.innerHTML =
+      "<style>" +
+      ".bar{display:flex;align-items:center;gap:1em;height:2.4em;padding:0 1em;font:bold 24px sans-serif;color:#fff;background:rgba(0,0,0," + opts.opacity + ");}" +
+      ".logo{height:1.8em}" +
+      ".ticker{flex:1;overflow:hidden;white-space:nowrap}" +
+      ".text{display:inline-block;will-change:transform}" +
+      "</style>" +
+      "<div class='bar'><img class='logo'><div class='ticker'><span class='text'></span></div><span class='clock'></span></div>";
+
+    var logo = root.querySelector(".logo");
+    if (opts.logo) logo.src = opts.logo;
+    else logo.remove();
+
+    var clock = root.querySelector(".clock");
+    if (opts.clock) {
+      var tick = function () { clock.textContent = formatTime(new Date(), opts.clockFormat); };
+      tick();
+      setInterval(tick, 1000);
+    } else {
+      clock.remove();
+    }
+
+    var ticker = root.querySelector(".ticker");
+    var text = root.querySelector(".text");
+    var pos = 0, last = null;
+    state.update = function () {
+      text.textContent = state.ticker || "";
+      ticker.style.visibility = state.ticker ? "visible" : "hidden";
+      pos = ticker.clientWidth;
+    };
+    state.update();
+
+    function step(t) {
+      if (last !== null && state.ticker) {
+        pos -= (opts.speed * (t - last)) / 1000;
+        if (pos < -text.offsetWidth) pos = ticker.clientWidth;
+        text.style.transform = "translateX(" + pos + "px)";
+      }
+      last = t;
+      requestAnimationFrame(step);
+    }
+    requestAnimationFrame(step);
+
+    document.documentElement.appendChild(host);
+  }
+
+  if (document.body) build();
+  else document.addEventListener("DOMContentLoaded", build);
+})`
+
+const setTickerScript = `(function (text) {
+  if (window.__kioskOverlay) window.__kioskOverlay.setTicker(text);
+})`
+
+// overlayLogo returns the logo as an image URL, reading local files into a
+// data URL so pages from any origin can show it.
+func overlayLogo(logo string) (string, error) {
+	if logo == "" || strings.Contains(logo, "://") || strings.HasPrefix(logo, "data:") {
+		return logo, nil
+	}
+
+	data, err := os.ReadFile(logo)
+	if err != nil {
+		return "", fmt.Errorf("failed to read logo %s: %w", logo, err)
+	}
+
+	mimeType := mime.TypeByExtension(filepath.Ext(logo))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+func (kiosk *Kiosk) overlayOptions(name string, overlay *config.OverlayConfig) (string, error) {
+	logo, err := overlayLogo(overlay.Logo)
+	if err != nil {
+		return "", err
+	}
+
+	position := overlay.Position
+	if position != config.OverlayTop {
+		position = config.OverlayBottom
+	}
+
+	opacity := overlay.Opacity
+	if opacity <= 0 {
+		opacity = defaultOverlayOpacity
+	}
+
+	clockFormat := overlay.ClockFormat
+	if clockFormat == "" {
+		clockFormat = defaultClockFormat
+	}
+
+	speed := overlay.TickerSpeed
+	if speed <= 0 {
+		speed = defaultTickerSpeed
+	}
+
+	kiosk.mu.Lock()
+	ticker := overlay.Ticker
+	if ds, ok := kiosk.windows[name]; ok {
+		ticker = ds.Ticker
+	}
+	kiosk.mu.Unlock()
+
+	opts, err := json.Marshal(map[string]interface{}{
+		"position":    position,
+		"opacity":     opacity,
+		"clock":       overlay.Clock,
+		"clockFormat": clockFormat,
+		"ticker":      ticker,
+		"speed":       speed,
+		"logo":        logo,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(opts), nil
+}
+
+// registerOverlay adds the display's overlay to every new document loaded in
+// the session, and to the current one. The ticker text is re-applied on every
+// load so runtime updates survive navigation.
+func (kiosk *Kiosk) registerOverlay(name string, tab *TabState, session *CDPSession) error {
+	kiosk.mu.Lock()
+	ds, ok := kiosk.windows[name]
+	var overlay *config.OverlayConfig
+	if ok {
+		overlay = ds.Config.Overlay
+	}
+	kiosk.mu.Unlock()
+
+	if overlay == nil {
+		return nil
+	}
+
+	opts, err := kiosk.overlayOptions(name, overlay)
+	if err != nil {
+		return err
+	}
+
+	script := fmt.Sprintf("%s(%s);", overlayScript, opts)
+	err = session.Call("Page.addScriptToEvaluateOnNewDocument", map[string]interface{}{
+		"source": script,
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	session.On("Page.domContentEventFired", func(json.RawMessage) {
+		go kiosk.applyTicker(name, tab, session)
+	})
+
+	if err := session.Call("Runtime.evaluate", map[string]interface{}{"expression": script}, nil); err != nil {
+		log.Printf("[%s] Error adding overlay to tab %s: %v", name, tab.URL, err)
+	}
+
+	return nil
+}
+
+func (kiosk *Kiosk) applyTicker(name string, tab *TabState, session *CDPSession) {
+	kiosk.mu.Lock()
+	ds, ok := kiosk.windows[name]
+	if !ok {
+		kiosk.mu.Unlock()
+		return
+	}
+	text := ds.Ticker
+	kiosk.mu.Unlock()
+
+	if err := evaluateWithArgs(session, setTickerScript, text); err != nil {
+		log.Printf("[%s] Error updating ticker of tab %s: %v", name, tab.URL, err)
+	}
+}
+
+// SetTicker replaces the ticker text of a display's overlay in all its tabs
+// without reloading them.
+func (kiosk *Kiosk) SetTicker(name string, text string) error {
+	kiosk.mu.Lock()
+	ds, ok := kiosk.windows[name]
+	if !ok {
+		kiosk.mu.Unlock()
+		return fmt.Errorf("display %s not found", name)
+	}
+	if ds.Config.Overlay == nil {
+		kiosk.mu.Unlock()
+		return fmt.Errorf("display %s has no overlay", name)
+	}
+	if ds.Ticker == text {
+		kiosk.mu.Unlock()
+		return nil
+	}
+	ds.Ticker = text
+
+	// Tabs without a session yet pick the text up when they connect
+	tabs := []*TabState{}
+	for _, tab := range ds.Tabs {
+		if tab.Session != nil {
+			tabs = append(tabs, tab)
+		}
+	}
+	kiosk.mu.Unlock()
+
+	log.Printf("[%s] Ticker set to %q", name, text)
+
+	for _, tab := range tabs {
+		session, err := kiosk.tabSession(name, tab)
+		if err != nil {
+			log.Printf("[%s] Error updating ticker of tab %s: %v", name, tab.URL, err)
+			continue
+		}
+
+		kiosk.applyTicker(name, tab, session)
+	}
+
+	return nil
+}
+
+// feedText returns the titles of an RSS or Atom feed joined into one line, or
+// the body itself if it isn't a feed.
+func feedText(body []byte) string {
+	trimmed := strings.TrimSpace(string(body))
+	if !strings.HasPrefix(trimmed, "<") {
+		return strings.Join(strings.Fields(trimmed), " ")
+	}
+
+	var titles []string
+	decoder := xml.NewDecoder(strings.NewReader(trimmed))
+	depth := 0
+	inTitle := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "item", "entry":
+				depth++
+			case "title":
+				inTitle = depth > 0
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "item", "entry":
+				depth--
+			case "title":
+				inTitle = false
+			}
+		case xml.CharData:
+			if inTitle {
+				if title := strings.TrimSpace(string(t)); title != "" {
+					titles = append(titles, title)
+				}
+			}
+		}
+	}
+
+	return strings.Join(titles, "  •  ")
+}
+
+func (kiosk *Kiosk) fetchTicker(name string, feedURL string) {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	resp, err := client.Get(feedURL)
+	if err != nil {
+		log.Printf("[%s] Error fetching ticker from %s: %v", name, feedURL, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		log.Printf("[%s] Error fetching ticker from %s: %s", name, feedURL, resp.Status)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		log.Printf("[%s] Error reading ticker from %s: %v", name, feedURL, err)
+		return
+	}
+
+	if err := kiosk.SetTicker(name, feedText(body)); err != nil {
+		log.Printf("[%s] Error setting ticker: %v", name, err)
+	}
+}
+
+// tickerWatcher periodically fetches the ticker text of displays whose
+// overlay has a tickerURL.
+func (kiosk *Kiosk) tickerWatcher(name string) {
+	kiosk.mu.Lock()
+	ds, ok := kiosk.windows[name]
+	var overlay *config.OverlayConfig
+	if ok {
+		overlay = ds.Config.Overlay
+	}
+	kiosk.mu.Unlock()
+
+	if overlay == nil || overlay.TickerURL == "" {
+		return
+	}
+
+	interval := time.Duration(overlay.TickerRefresh) * time.Second
+	if interval <= 0 {
+		interval = defaultTickerRefresh * time.Second
+	}
+
+	kiosk.wg.Add(1)
+	go func() {
+		defer kiosk.wg.Done()
+
+		for {
+			kiosk.fetchTicker(name, overlay.TickerURL)
+
+			select {
+			case <-time.After(interval):
+			case <-kiosk.ctx.Done():
+				return
+			}
+		}
+	}()
+}
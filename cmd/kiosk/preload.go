@@ -0,0 +1,82 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// tabDueForRefresh reports whether the tab would be refreshed before it is
+// activated. kiosk.mu must be held.
+func tabDueForRefresh(tab *TabState) bool {
+	return tab.RefreshBeforeLoad || refreshIntervalDue(tab)
+}
+
+// preloadNext refreshes the tab after index i in the background, so it is
+// loaded by the time the current tab's dwell ends.
+func (kiosk *Kiosk) preloadNext(name string, display *DisplayState, i int) {
+	if !display.Config.Preload || len(display.Tabs) < 2 {
+		return
+	}
+
+	next := display.Tabs[(i+1)%len(display.Tabs)]
+
+	kiosk.mu.Lock()
+	skip := next.Failed || next.preloadDone != nil || !tabDueForRefresh(next)
+	if !skip {
+		next.Preloading = true
+		next.preloadDone = make(chan struct{})
+	}
+	kiosk.mu.Unlock()
+
+	if skip {
+		return
+	}
+
+	log.Printf("[%s] Preloading tab %s", name, next.URL)
+
+	kiosk.wg.Add(1)
+	go func() {
+		defer kiosk.wg.Done()
+
+		refreshed, _ := kiosk.refreshTabAndWait(next, name)
+
+		kiosk.mu.Lock()
+		next.Preloading = false
+		next.preloaded = refreshed
+		if refreshed {
+			next.PreloadedAt = time.Now()
+		}
+		close(next.preloadDone)
+		kiosk.mu.Unlock()
+	}()
+}
+
+// awaitPreload waits for a running preload of the tab and reports whether it
+// left the tab freshly loaded.
+func (kiosk *Kiosk) awaitPreload(name string, tab *TabState) bool {
+	kiosk.mu.Lock()
+	done := tab.preloadDone
+	kiosk.mu.Unlock()
+
+	if done == nil {
+		return false
+	}
+
+	select {
+	case <-done:
+	case <-kiosk.ctx.Done():
+		return false
+	}
+
+	kiosk.mu.Lock()
+	preloaded := tab.preloaded
+	tab.preloadDone = nil
+	tab.preloaded = false
+	kiosk.mu.Unlock()
+
+	if preloaded {
+		log.Printf("[%s] Tab %s was preloaded", name, tab.URL)
+	}
+
+	return preloaded
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"kiosk/internal/config"
+)
+
+// profileMarker is created in profile directories made by the kiosk. Only
+// directories carrying it are ever deleted.
+const profileMarker = ".kiosk-profile"
+
+var (
+	exitedCleanlyRe = regexp.MustCompile(`"exited_cleanly":\s*false`)
+	exitTypeRe      = regexp.MustCompile(`"exit_type":\s*"[^"]+"`)
+)
+
+func (kiosk *Kiosk) profilePolicy(name string) string {
+	window, ok := kiosk.windows[name]
+	if !ok || window.Config.Profile.Policy == "" {
+		return config.ProfileEphemeral
+	}
+
+	return window.Config.Profile.Policy
+}
+
+// profileDir returns the browser user data dir of a display.
+func (kiosk *Kiosk) profileDir(name string) string {
+	if window, ok := kiosk.windows[name]; ok && window.Config.Profile.Dir != "" {
+		return window.Config.Profile.Dir
+	}
+
+	return kiosk.browserUserDataDir(name)
+}
+
+// prepareProfile gets a display's user data dir ready for launch according to
+// its profile policy and returns its path.
+func (kiosk *Kiosk) prepareProfile(name string) (string, error) {
+	dir := kiosk.profileDir(name)
+
+	switch policy := kiosk.profilePolicy(name); policy {
+	case config.ProfileEphemeral:
+		if err := kiosk.removeProfile(name, dir); err != nil {
+			return "", err
+		}
+	case config.ProfilePersistent:
+	case config.ProfileTemplate:
+		template := kiosk.windows[name].Config.Profile.Template
+		if template == "" {
+			return "", fmt.Errorf("profile policy %s requires a template directory", policy)
+		}
+
+		if err := kiosk.removeProfile(name, dir); err != nil {
+			return "", err
+		}
+		if err := copyDir(template, dir); err != nil {
+			return "", fmt.Errorf("failed to seed profile from %s: %w", template, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, profileMarker), nil, 0644); err != nil {
+			return "", err
+		}
+
+		// Locks copied from a template belong to whichever browser last used it
+		for _, lock := range []string{"SingletonLock", "SingletonCookie", "SingletonSocket"} {
+			os.Remove(filepath.Join(dir, lock))
+		}
+	default:
+		return "", fmt.Errorf("unknown profile policy %q, expected one of %v", policy, config.ProfilePolicies)
+	}
+
+	if err := createProfile(dir); err != nil {
+		return "", err
+	}
+
+	markExitedCleanly(name, dir)
+	return dir, nil
+}
+
+// cleanupProfile removes a display's user data dir once its browser has been
+// closed, unless the profile is meant to be kept.
+func (kiosk *Kiosk) cleanupProfile(name string) {
+	if kiosk.profilePolicy(name) != config.ProfileEphemeral {
+		return
+	}
+
+	if err := kiosk.removeProfile(name, kiosk.profileDir(name)); err != nil {
+		log.Printf("[%s] Error removing browser profile: %v", name, err)
+	}
+}
+
+// ownedProfile reports whether a profile directory may be deleted: it is
+// missing, empty or was created by the kiosk.
+func ownedProfile(dir string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if len(entries) == 0 {
+		return true, nil
+	}
+
+	_, err = os.Stat(filepath.Join(dir, profileMarker))
+	return err == nil, nil
+}
+
+// removeProfile deletes a display's profile directory. Directories set in the
+// config are only deleted if the kiosk created them, so a misconfigured dir
+// can't wipe unrelated data.
+func (kiosk *Kiosk) removeProfile(name, dir string) error {
+	if window, ok := kiosk.windows[name]; !ok || window.Config.Profile.Dir == "" {
+		return os.RemoveAll(dir)
+	}
+
+	owned, err := ownedProfile(dir)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		return fmt.Errorf("refusing to remove %s: not created by the kiosk (no %s file)", dir, profileMarker)
+	}
+
+	return os.RemoveAll(dir)
+}
+
+// createProfile creates a profile directory and marks it as the kiosk's own,
+// unless it already held data from elsewhere.
+func createProfile(dir string) error {
+	owned, err := ownedProfile(dir)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if !owned {
+		return nil
+	}
+
+	return os.WriteFile(filepath.Join(dir, profileMarker), nil, 0644)
+}
+
+// markExitedCleanly flags a reused profile as having shut down normally so the
+// browser doesn't show a "restore pages" prompt after it was killed.
+func markExitedCleanly(name string, dir string) {
+	files := []string{
+		filepath.Join(dir, "Local State"),
+		filepath.Join(dir, "Default", "Preferences"),
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+
+		fixed := exitedCleanlyRe.ReplaceAll(data, []byte(`"exited_cleanly":true`))
+		fixed = exitTypeRe.ReplaceAll(fixed, []byte(`"exit_type":"Normal"`))
+		if string(fixed) == string(data) {
+			continue
+		}
+
+		if err := os.WriteFile(file, fixed, 0644); err != nil {
+			log.Printf("[%s] Error fixing up %s: %v", name, file, err)
+		}
+	}
+}
+
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+
+		return nil
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
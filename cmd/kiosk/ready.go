@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"kiosk/internal/config"
+)
+
+const defaultLoadTimeout = 30 * time.Second
+
+func tabLoadTimeout(tab *TabState) time.Duration {
+	if tab.LoadTimeout > 0 {
+		return time.Duration(tab.LoadTimeout) * time.Second
+	}
+
+	return defaultLoadTimeout
+}
+
+// refreshDelay returns the fixed wait after a refresh. It's only kept for
+// tabs that wait for nothing, readiness events and selectors replace it.
+func refreshDelay(tab *TabState) time.Duration {
+	if tab.DelayAfterRefresh <= 0 || tab.WaitFor != config.WaitForNone || tab.WaitForSelector != "" {
+		return 0
+	}
+
+	return time.Duration(tab.DelayAfterRefresh) * time.Second
+}
+
+// loadAndWait refreshes the tab according to its refresh mode and waits until
+// the page is ready according to its waitFor and waitForSelector settings. Any
+// Chromium lifecycle event name (e.g. DOMContentLoaded) can be waited for. A
+// page that doesn't become ready within the load timeout is logged and shown
+// anyway.
+func (kiosk *Kiosk) loadAndWait(name string, tab *TabState) error {
+	session, err := kiosk.tabSession(name, tab)
+	if err != nil {
+		return err
+	}
+
+	mode := kiosk.refreshMode(tab)
+
+	event := tab.WaitFor
+	if event == "" {
+		event = config.WaitForLoad
+	}
+
+	// A refresh script doesn't necessarily load a new document
+	if mode == config.RefreshRunScript {
+		event = config.WaitForNone
+	}
+
+	// Subscribe before navigating so no lifecycle event is missed
+	ready := make(chan struct{})
+	if event != config.WaitForNone {
+		seenInit := false
+		closed := false
+
+		off := session.On("Page.lifecycleEvent", func(params json.RawMessage) {
+			var lifecycle struct {
+				FrameID string `json:"frameId"`
+				Name    string `json:"name"`
+			}
+			if err := json.Unmarshal(params, &lifecycle); err != nil || lifecycle.FrameID != tab.ID {
+				return
+			}
+
+			// Events before "init" belong to the document being replaced
+			if lifecycle.Name == "init" {
+				seenInit = true
+				return
+			}
+
+			if seenInit && !closed && lifecycle.Name == event {
+				closed = true
+				close(ready)
+			}
+		})
+		defer off()
+	} else {
+		close(ready)
+	}
+
+	start := time.Now()
+	if err := kiosk.refreshPage(name, tab, mode); err != nil {
+		return err
+	}
+
+	deadline := start.Add(tabLoadTimeout(tab))
+
+	select {
+	case <-ready:
+	case <-time.After(time.Until(deadline)):
+		log.Printf("[%s] Tab %s not ready after %v, showing it anyway", name, tab.URL, tabLoadTimeout(tab))
+		return nil
+	case <-kiosk.ctx.Done():
+		return kiosk.ctx.Err()
+	}
+
+	if tab.WaitForSelector != "" {
+		err := kiosk.waitForSelector(session, tab.WaitForSelector, time.Until(deadline))
+		if err != nil {
+			log.Printf("[%s] Tab %s not ready: %v", name, tab.URL, err)
+			return nil
+		}
+	}
+
+	log.Printf("[%s] Tab %s ready after %v", name, tab.URL, time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"kiosk/internal/config"
+)
+
+// refreshMode returns how the tab is refreshed. Tabs not showing their own
+// page (e.g. the fallback page) are always navigated back to their URL.
+func (kiosk *Kiosk) refreshMode(tab *TabState) string {
+	kiosk.mu.Lock()
+	needsNavigate := tab.needsNavigate
+	kiosk.mu.Unlock()
+
+	switch {
+	case needsNavigate:
+		return config.RefreshNavigate
+	case tab.RefreshMode == config.RefreshRunScript && tab.RefreshScript == "":
+		return config.RefreshNavigate
+	case tab.RefreshMode == "":
+		return config.RefreshNavigate
+	}
+
+	return tab.RefreshMode
+}
+
+// refreshPage refreshes the tab's page using the given refresh mode.
+func (kiosk *Kiosk) refreshPage(name string, tab *TabState, mode string) error {
+	switch mode {
+	case config.RefreshReload:
+		return kiosk.refreshChromeTab(name, tab)
+	case config.RefreshSoftReload:
+		return kiosk.chromeWebsocketSend(name, tab, "Page.reload", map[string]interface{}{"ignoreCache": false})
+	case config.RefreshRunScript:
+		script, err := loadInjection(tab.RefreshScript)
+		if err != nil {
+			return err
+		}
+
+		session, err := kiosk.tabSession(name, tab)
+		if err != nil {
+			return err
+		}
+
+		return session.Evaluate(script, nil)
+	default:
+		return kiosk.navigateChromeTab(name, tab)
+	}
+}
+
+// refreshIntervalDue reports whether the tab's refresh interval has elapsed
+// and the refresh is left to the tab cycler. kiosk.mu must be held.
+func refreshIntervalDue(tab *TabState) bool {
+	if tab.RefreshTimer || tab.RefreshInterval <= 0 {
+		return false
+	}
+
+	return time.Since(time.Unix(tab.LastRefresh, 0)) > time.Duration(tab.RefreshInterval)*time.Second
+}
+
+// refreshTimers refreshes the display's tabs that have refreshTimer set every
+// refreshInterval, independently of the tab rotation.
+func (kiosk *Kiosk) refreshTimers(name string) {
+	kiosk.mu.Lock()
+	display, ok := kiosk.windows[name]
+	kiosk.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	for _, tab := range display.Tabs {
+		if !tab.RefreshTimer || tab.RefreshInterval <= 0 {
+			continue
+		}
+
+		interval := time.Duration(tab.RefreshInterval) * time.Second
+
+		kiosk.wg.Add(1)
+		go func(tab *TabState) {
+			defer kiosk.wg.Done()
+
+			for {
+				select {
+				case <-time.After(interval):
+				case <-kiosk.ctx.Done():
+					return
+				}
+
+				// Broken tabs are retried by the tab cycler, and pages in use
+				// on interactive displays are left alone
+				kiosk.mu.Lock()
+				failed, interacting := tab.Failed, display.Interacting
+				kiosk.mu.Unlock()
+
+				if failed || interacting {
+					continue
+				}
+
+				log.Printf("[%s] Refresh timer fired for tab %s", name, tab.URL)
+				kiosk.refreshTabAndWait(tab, name)
+			}
+		}(tab)
+	}
+}
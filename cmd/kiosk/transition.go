@@ -0,0 +1,84 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"kiosk/internal/config"
+)
+
+const defaultTransitionDuration = 500
+
+// transitionScript animates a black cover over the page. "in" covers the page
+// and resolves once it is hidden, "cover" hides it instantly, "out" reveals the
+// page again and "remove" drops the cover without animating.
+const transitionScript = `(function (mode, phase, duration) {
+  var cover = document.getElementById("__kiosk-transition");
+  if (phase === "remove") {
+    if (cover) cover.remove();
+    return;
+  }
+  if (!cover) {
+    cover = document.createElement("div");
+    cover.id = "__kiosk-transition";
+    cover.style.cssText = "position:fixed;inset:0;background:#000;z-index:2147483647;pointer-events:none;";
+    document.documentElement.appendChild(cover);
+  }
+
+  var shown = mode === "slide" ? { transform: "translateX(0)" } : { opacity: 1 };
+  var before = mode === "slide" ? { transform: "translateX(100%)" } : { opacity: 0 };
+  var after = mode === "slide" ? { transform: "translateX(-100%)" } : { opacity: 0 };
+
+  if (phase === "cover") {
+    Object.assign(cover.style, shown);
+    return;
+  }
+
+  var frames = phase === "in" ? [before, shown] : [shown, after];
+  var animation = cover.animate(frames, { duration: duration, easing: "ease-in-out", fill: "forwards" });
+  return animation.finished.then(function () {
+    if (phase === "out") cover.remove();
+  });
+})`
+
+func transitionDuration(display config.DisplayConfig) time.Duration {
+	if display.TransitionDuration > 0 {
+		return time.Duration(display.TransitionDuration) * time.Millisecond
+	}
+
+	return defaultTransitionDuration * time.Millisecond
+}
+
+func (kiosk *Kiosk) transitionStep(name string, tab *TabState, mode, phase string, duration time.Duration) {
+	session, err := kiosk.tabSession(name, tab)
+	if err != nil {
+		log.Printf("[%s] Error animating tab %s: %v", name, tab.URL, err)
+		return
+	}
+
+	if err := evaluateWithArgs(session, transitionScript, mode, phase, duration.Milliseconds()); err != nil {
+		log.Printf("[%s] Error animating tab %s: %v", name, tab.URL, err)
+	}
+}
+
+// switchTab activates a tab using the display's transition. Fades and slides
+// cover the current tab, activate the next one behind a cover of its own and
+// then reveal it, so the repaint of the switch is never visible.
+func (kiosk *Kiosk) switchTab(name string, display *DisplayState, from, to *TabState) error {
+	mode := display.Config.Transition
+	if from == nil || from == to || (mode != config.TransitionFade && mode != config.TransitionSlide) {
+		return kiosk.activateChromeTab(display.DebugPort, to.ID)
+	}
+
+	duration := transitionDuration(display.Config)
+
+	kiosk.transitionStep(name, from, mode, "in", duration)
+	kiosk.transitionStep(name, to, mode, "cover", duration)
+
+	err := kiosk.activateChromeTab(display.DebugPort, to.ID)
+
+	kiosk.transitionStep(name, to, mode, "out", duration)
+	kiosk.transitionStep(name, from, mode, "remove", duration)
+
+	return err
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// Zoom uses CSS zoom rather than Emulation.setPageScaleFactor. The page scale
+// factor is pinch zoom: it magnifies the page without laying it out again, so
+// a dashboard zoomed by 2 would show its top left quarter. CSS zoom lays the
+// page out at the smaller size like the browser's own zoom, which CDP has no
+// call for. Pinch zoom is available as emulation.pageScaleFactor.
+const zoomScript = `(function (zoom) {
+  document.documentElement.style.zoom = zoom;
+})`
+
+const scrollScript = `(function (selector, x, y) {
+  if (selector) {
+    var el = document.querySelector(selector);
+    if (!el) throw new Error("element not found: " + selector);
+    el.scrollIntoView({ block: "start" });
+    return;
+  }
+  window.scrollTo(x, y);
+})`
+
+// registerView applies the tab's device metrics emulation, which lasts for the
+// session, and re-applies its zoom and scroll position after every load.
+func (kiosk *Kiosk) registerView(name string, tab *TabState, session *CDPSession) error {
+	if e := tab.Emulation; e != nil {
+		err := session.Call("Emulation.setDeviceMetricsOverride", map[string]interface{}{
+			"width":             e.Width,
+			"height":            e.Height,
+			"deviceScaleFactor": e.DeviceScaleFactor,
+			"mobile":            e.Mobile,
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("failed to emulate device metrics: %w", err)
+		}
+
+		if e.PageScaleFactor > 0 {
+			err := session.Call("Emulation.setPageScaleFactor", map[string]interface{}{
+				"pageScaleFactor": e.PageScaleFactor,
+			}, nil)
+			if err != nil {
+				return fmt.Errorf("failed to set page scale factor: %w", err)
+			}
+		}
+	}
+
+	if tab.Zoom <= 0 && tab.ScrollTo == nil {
+		return nil
+	}
+
+	session.On("Page.loadEventFired", func(json.RawMessage) {
+		go kiosk.applyView(name, tab, session)
+	})
+
+	go kiosk.applyView(name, tab, session)
+	return nil
+}
+
+// applyView sets the zoom and scroll position of the loaded document.
+func (kiosk *Kiosk) applyView(name string, tab *TabState, session *CDPSession) {
+	if tab.Zoom > 0 {
+		if err := evaluateWithArgs(session, zoomScript, tab.Zoom); err != nil {
+			log.Printf("[%s] Error zooming tab %s: %v", name, tab.URL, err)
+		}
+	}
+
+	if s := tab.ScrollTo; s != nil {
+		if err := evaluateWithArgs(session, scrollScript, s.Selector, s.X, s.Y); err != nil {
+			log.Printf("[%s] Error scrolling tab %s: %v", name, tab.URL, err)
+		}
+	}
+}
@@ -14,37 +14,326 @@ import (
 )
 
 type TabConfig struct {
-	URL               string `json:"URL" yaml:"url"`
-	RefreshBeforeLoad bool   `json:"RefreshBeforeLoad" yaml:"refreshBeforeLoad"`
-	RefreshAfterLoad  bool   `json:"RefreshAfterLoad" yaml:"refreshAfterLoad"`
-	RefreshInterval   int    `json:"RefreshInterval" yaml:"refreshInterval"`
-	DelayAfterRefresh int    `json:"DelayAfterRefresh" yaml:"delayAfterRefresh"`
-	DwellTime         int    `json:"DwellTime" yaml:"dwellTime"`
+	URL               string                `json:"URL" yaml:"url"`
+	Type              string                `json:"Type" yaml:"type"`                   // What the tab shows: url (default), image, video, directory, markdown or html
+	Content           string                `json:"Content" yaml:"content"`             // Inline markdown or HTML, used instead of the file at url
+	SlideInterval     int                   `json:"SlideInterval" yaml:"slideInterval"` // Seconds each image or markdown slide is shown (default 10)
+	RefreshBeforeLoad bool                  `json:"RefreshBeforeLoad" yaml:"refreshBeforeLoad"`
+	RefreshAfterLoad  bool                  `json:"RefreshAfterLoad" yaml:"refreshAfterLoad"`
+	RefreshInterval   int                   `json:"RefreshInterval" yaml:"refreshInterval"`
+	RefreshMode       string                `json:"RefreshMode" yaml:"refreshMode"`             // How the tab is refreshed: navigate (default), reload, softReload or script
+	RefreshScript     string                `json:"RefreshScript" yaml:"refreshScript"`         // Inline JavaScript or path to a script run by the script refresh mode
+	RefreshTimer      bool                  `json:"RefreshTimer" yaml:"refreshTimer"`           // Refresh every refreshInterval on a timer, whether or not the tab is shown
+	DelayAfterRefresh int                   `json:"DelayAfterRefresh" yaml:"delayAfterRefresh"` // Deprecated: seconds waited after a refresh, only with waitFor none and no waitForSelector
+	DwellTime         int                   `json:"DwellTime" yaml:"dwellTime"`
+	WaitFor           string                `json:"WaitFor" yaml:"waitFor"`                 // Page readiness waited for after a refresh: load (default), networkIdle or none
+	WaitForSelector   string                `json:"WaitForSelector" yaml:"waitForSelector"` // CSS selector that must appear before the page is ready
+	LoadTimeout       int                   `json:"LoadTimeout" yaml:"loadTimeout"`         // Seconds to wait for the page to become ready (default 30)
+	Zoom              float64               `json:"Zoom" yaml:"zoom"`                       // Page zoom, e.g. 2 to show a 1080p dashboard full size on a 4K screen (0 = unchanged)
+	ScrollTo          *ScrollConfig         `json:"ScrollTo,omitempty" yaml:"scrollTo,omitempty"`
+	Emulation         *EmulationConfig      `json:"Emulation,omitempty" yaml:"emulation,omitempty"`
+	AutoScroll        *AutoScrollConfig     `json:"AutoScroll,omitempty" yaml:"autoScroll,omitempty"` // Scroll long pages while the tab is shown
+	InjectCSS         string                `json:"InjectCSS" yaml:"injectCSS"`                       // Inline CSS or path to a CSS file applied to every page load
+	InjectJS          string                `json:"InjectJS" yaml:"injectJS"`                         // Inline JavaScript or path to a script evaluated on every page load
+	Login             *LoginConfig          `json:"Login,omitempty" yaml:"login,omitempty"`
+	Headers           []HeaderConfig        `json:"Headers,omitempty" yaml:"headers,omitempty"` // Extra HTTP headers sent with every request
+	Cookies           []CookieConfig        `json:"Cookies,omitempty" yaml:"cookies,omitempty"` // Cookies set before the first navigation
+	BasicAuth         *BasicAuthConfig      `json:"BasicAuth,omitempty" yaml:"basicAuth,omitempty"`
+	HealthCheck       *HealthCheckConfig    `json:"HealthCheck,omitempty" yaml:"healthCheck,omitempty"`
+	Actions           *ActionsConfig        `json:"Actions,omitempty" yaml:"actions,omitempty"`               // Key and mouse actions sent to the tab
+	NavigationLock    *NavigationLockConfig `json:"NavigationLock,omitempty" yaml:"navigationLock,omitempty"` // Keep the tab on its configured site
 }
 
+const (
+	TabURL       = "url"
+	TabImage     = "image"
+	TabVideo     = "video"
+	TabDirectory = "directory"
+	TabMarkdown  = "markdown"
+	TabHTML      = "html"
+)
+
+var TabTypes = []string{TabURL, TabImage, TabVideo, TabDirectory, TabMarkdown, TabHTML}
+
+// IsLocalContent reports whether the tab shows local files through the kiosk
+// web server rather than a web page.
+func (t TabConfig) IsLocalContent() bool {
+	return t.Type != "" && t.Type != TabURL
+}
+
+const (
+	RefreshNavigate   = "navigate"
+	RefreshReload     = "reload"
+	RefreshSoftReload = "softReload"
+	RefreshRunScript  = "script"
+)
+
+var RefreshModes = []string{RefreshNavigate, RefreshReload, RefreshSoftReload, RefreshRunScript}
+
+const (
+	WaitForLoad        = "load"
+	WaitForNetworkIdle = "networkIdle"
+	WaitForNone        = "none"
+)
+
+var WaitForEvents = []string{WaitForLoad, WaitForNetworkIdle, WaitForNone}
+
+type ScrollConfig struct {
+	Selector string `json:"Selector" yaml:"selector"` // Element scrolled to the top of the viewport, takes precedence over x/y
+	X        int    `json:"X" yaml:"x"`
+	Y        int    `json:"Y" yaml:"y"`
+}
+
+type EmulationConfig struct {
+	Width             int     `json:"Width" yaml:"width"`                         // Emulated viewport width (0 = window width)
+	Height            int     `json:"Height" yaml:"height"`                       // Emulated viewport height (0 = window height)
+	DeviceScaleFactor float64 `json:"DeviceScaleFactor" yaml:"deviceScaleFactor"` // Emulated device pixel ratio (0 = unchanged)
+	Mobile            bool    `json:"Mobile" yaml:"mobile"`
+	PageScaleFactor   float64 `json:"PageScaleFactor" yaml:"pageScaleFactor"` // Page scale factor applied on top of the emulated metrics (0 = unchanged)
+}
+
+type AutoScrollConfig struct {
+	Speed           int  `json:"Speed" yaml:"speed"`                     // Pixels per second (default 50)
+	PauseTop        int  `json:"PauseTop" yaml:"pauseTop"`               // Seconds to pause at the top before scrolling
+	PauseBottom     int  `json:"PauseBottom" yaml:"pauseBottom"`         // Seconds to pause at the bottom before looping
+	Loop            bool `json:"Loop" yaml:"loop"`                       // Jump back to the top and scroll again, otherwise scroll once
+	DwellFromHeight bool `json:"DwellFromHeight" yaml:"dwellFromHeight"` // Show the tab just long enough to scroll through it once
+}
+
+type LoginStep struct {
+	Fill       string `json:"Fill" yaml:"fill"`             // Selector of an input to fill
+	Value      string `json:"Value" yaml:"value"`           // Literal value to fill
+	Secret     string `json:"Secret" yaml:"secret"`         // Name of a secret (secrets file entry or environment variable) to fill
+	Click      string `json:"Click" yaml:"click"`           // Selector of an element to click
+	WaitForURL string `json:"WaitForURL" yaml:"waitForURL"` // Regular expression the page URL must match before continuing
+	Wait       int    `json:"Wait" yaml:"wait"`             // Milliseconds to wait
+}
+
+type LoginConfig struct {
+	URL     string      `json:"URL" yaml:"url"`         // Regular expression matching the login page URL that triggers the steps
+	Steps   []LoginStep `json:"Steps" yaml:"steps"`     // Steps executed in order on the login page
+	Timeout int         `json:"Timeout" yaml:"timeout"` // Seconds each step may wait for its selector or URL (default 30)
+}
+
+type HeaderConfig struct {
+	Name   string `json:"Name" yaml:"name"`
+	Value  string `json:"Value" yaml:"value"`   // Literal header value
+	Secret string `json:"Secret" yaml:"secret"` // Name of a secret holding the header value
+}
+
+type CookieConfig struct {
+	Name     string `json:"Name" yaml:"name"`
+	Value    string `json:"Value" yaml:"value"`   // Literal cookie value
+	Secret   string `json:"Secret" yaml:"secret"` // Name of a secret holding the cookie value
+	Domain   string `json:"Domain" yaml:"domain"` // Defaults to the tab URL's host
+	Path     string `json:"Path" yaml:"path"`
+	Secure   bool   `json:"Secure" yaml:"secure"`
+	HTTPOnly bool   `json:"HTTPOnly" yaml:"httpOnly"`
+}
+
+type BasicAuthConfig struct {
+	Username       string `json:"Username" yaml:"username"`
+	UsernameSecret string `json:"UsernameSecret" yaml:"usernameSecret"` // Name of a secret holding the username, overrides username
+	PasswordSecret string `json:"PasswordSecret" yaml:"passwordSecret"` // Name of a secret holding the password
+}
+
+const (
+	HealthReload = "reload"
+	HealthSkip   = "skip"
+	HealthAlert  = "alert"
+)
+
+var HealthActions = []string{HealthReload, HealthSkip, HealthAlert}
+
+type HealthCheckConfig struct {
+	Selector   string   `json:"Selector" yaml:"selector"`     // CSS selector that must exist
+	TextAbsent []string `json:"TextAbsent" yaml:"textAbsent"` // Text that must not appear on the page
+	Expression string   `json:"Expression" yaml:"expression"` // JavaScript expression that must return true
+	Delay      int      `json:"Delay" yaml:"delay"`           // Seconds after the load event before checking (default 5)
+	Action     string   `json:"Action" yaml:"action"`         // What to do on failure: reload (default), skip or alert
+}
+
+const (
+	NavigationBack = "back"
+	NavigationHome = "home"
+)
+
+type NavigationLockConfig struct {
+	Allow       []string `json:"Allow" yaml:"allow"`             // Regular expressions of allowed URLs (default: the tab URL's origin)
+	Action      string   `json:"Action" yaml:"action"`           // Where disallowed navigations are reverted to: back (default, the last allowed page) or home (the tab URL)
+	ReturnAfter int      `json:"ReturnAfter" yaml:"returnAfter"` // Seconds after which a tab that left its URL returns to it (0 = never)
+}
+
+const (
+	RestartNever     = "never"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+)
+
+var RestartPolicies = []string{RestartNever, RestartOnFailure, RestartAlways}
+
 type ExecConfig struct {
-	Command             string   `json:"Command" yaml:"command"`
-	Args                []string `json:"Args" yaml:"args"` // Arguments for the command
-	WindowSearch        string   `json:"WindowSearch" yaml:"windowSearch"`
-	DelayBeforeSendKeys int      `json:"DelayBeforeSendKeys" yaml:"delayBeforeSendKeys"` // Delay before sending keys after command execution
-	SendKeys            []string `json:"SendKeys" yaml:"sendKeys"`                       // List of keys to send after the command is executed
+	Command             string         `json:"Command" yaml:"command"`
+	Args                []string       `json:"Args" yaml:"args"` // Arguments for the command
+	WindowSearch        string         `json:"WindowSearch" yaml:"windowSearch"`
+	DelayBeforeSendKeys int            `json:"DelayBeforeSendKeys" yaml:"delayBeforeSendKeys"` // Delay before sending keys after command execution
+	SendKeys            []string       `json:"SendKeys" yaml:"sendKeys"`                       // List of keys to send after the command is executed
+	Restart             string         `json:"Restart" yaml:"restart"`                         // Restart policy when the command exits: never (default), on-failure or always
+	RestartDelay        int            `json:"RestartDelay" yaml:"restartDelay"`               // Seconds before the first restart (default 1), doubled after each quick exit
+	MaxRestartDelay     int            `json:"MaxRestartDelay" yaml:"maxRestartDelay"`         // Upper bound of the restart backoff in seconds (default 60)
+	Env                 []string       `json:"Env" yaml:"env"`                                 // Extra environment variables as KEY=value
+	WorkingDir          string         `json:"WorkingDir" yaml:"workingDir"`                   // Directory the command runs in
+	Shell               bool           `json:"Shell" yaml:"shell"`                             // Run the command through sh -c, with args as its positional parameters
+	User                string         `json:"User" yaml:"user"`                               // User name or uid to run the command as
+	Actions             *ActionsConfig `json:"Actions,omitempty" yaml:"actions,omitempty"`     // Key and mouse actions sent to the command's window
+}
+
+type ClickAction struct {
+	X      int `json:"X" yaml:"x"`
+	Y      int `json:"Y" yaml:"y"`
+	Button int `json:"Button" yaml:"button"` // Mouse button: 1 left (default), 2 middle, 3 right
+}
+
+type Action struct {
+	Key          string       `json:"Key" yaml:"key"`                         // Key or combination in xdotool notation, e.g. ctrl+r or F5
+	Type         string       `json:"Type" yaml:"type"`                       // Text to type
+	Click        *ClickAction `json:"Click,omitempty" yaml:"click,omitempty"` // Click at a position relative to the window or page
+	Wait         int          `json:"Wait" yaml:"wait"`                       // Milliseconds to wait
+	WaitForTitle string       `json:"WaitForTitle" yaml:"waitForTitle"`       // Regular expression the window or page title must match before continuing
+}
+
+type ActionsConfig struct {
+	Launch   []Action `json:"Launch" yaml:"launch"`     // Run once after the window appears or the tab is first shown
+	Activate []Action `json:"Activate" yaml:"activate"` // Run each time the tab is activated (tabs only)
+	Timer    []Action `json:"Timer" yaml:"timer"`       // Run every interval while the window or tab is shown
+	Interval int      `json:"Interval" yaml:"interval"` // Seconds between timer runs
+	Timeout  int      `json:"Timeout" yaml:"timeout"`   // Seconds waitForTitle may wait (default 30)
+}
+
+const (
+	FailureFallback = "fallback"
+	FailureSkip     = "skip"
+)
+
+type FailureConfig struct {
+	Action           string `json:"Action" yaml:"action"`                     // What to show while a tab is broken: fallback (default) or skip
+	FallbackURL      string `json:"FallbackURL" yaml:"fallbackURL"`           // Page shown in place of a broken tab, defaults to a built-in "temporarily unavailable" page
+	RetryInterval    int    `json:"RetryInterval" yaml:"retryInterval"`       // Seconds before the first retry of a broken tab (default 10), doubled after each failed retry
+	MaxRetryInterval int    `json:"MaxRetryInterval" yaml:"maxRetryInterval"` // Upper bound of the retry backoff in seconds (default 300)
+}
+
+type BrowserConfig struct {
+	Binary       string   `json:"Binary" yaml:"binary"`             // Browser binary name or path, auto-detected if empty
+	WindowSearch string   `json:"WindowSearch" yaml:"windowSearch"` // Window name search used to find browser windows (used by xdotool search)
+	ExtraFlags   []string `json:"ExtraFlags" yaml:"extraFlags"`     // Flags added to the initial browser launch
+	RemoveFlags  []string `json:"RemoveFlags" yaml:"removeFlags"`   // Default flags to leave out of the initial browser launch
+	UserDataDir  string   `json:"UserDataDir" yaml:"userDataDir"`   // Base directory for per-display user data dirs
+}
+
+const (
+	ProfileEphemeral  = "ephemeral"
+	ProfilePersistent = "persistent"
+	ProfileTemplate   = "template"
+)
+
+var ProfilePolicies = []string{ProfileEphemeral, ProfilePersistent, ProfileTemplate}
+
+type ProfileConfig struct {
+	Policy   string `json:"Policy" yaml:"policy"`     // ephemeral (default), persistent or template
+	Dir      string `json:"Dir" yaml:"dir"`           // Profile directory, defaults to a per-display directory under browser.userDataDir
+	Template string `json:"Template" yaml:"template"` // Profile directory copied into the profile before every launch (template policy)
+}
+
+type RegionConfig struct {
+	X               float64  `json:"X" yaml:"x"`                             // Left edge in percent of the display, regions without a width/height are placed on the grid
+	Y               float64  `json:"Y" yaml:"y"`                             // Top edge in percent of the display
+	Width           float64  `json:"Width" yaml:"width"`                     // Width in percent of the display
+	Height          float64  `json:"Height" yaml:"height"`                   // Height in percent of the display
+	URLs            []string `json:"URLs" yaml:"urls"`                       // URLs rotated through in this region
+	DwellTime       int      `json:"DwellTime" yaml:"dwellTime"`             // Seconds each URL is shown (default: top-level dwellTime)
+	RefreshInterval int      `json:"RefreshInterval" yaml:"refreshInterval"` // Seconds between reloads of the region (0 = disable)
+}
+
+type LayoutConfig struct {
+	Columns int            `json:"Columns" yaml:"columns"` // Grid columns (default: enough for a square grid)
+	Rows    int            `json:"Rows" yaml:"rows"`       // Grid rows (default: enough for all grid regions)
+	Regions []RegionConfig `json:"Regions" yaml:"regions"`
+}
+
+const (
+	TransitionCut   = "cut"
+	TransitionFade  = "fade"
+	TransitionSlide = "slide"
+)
+
+var Transitions = []string{TransitionCut, TransitionFade, TransitionSlide}
+
+const DefaultIdleTimeout = 60
+
+type InteractiveConfig struct {
+	IdleTimeout int  `json:"IdleTimeout" yaml:"idleTimeout"` // Seconds without input before the display is reset (default 60)
+	KeepSession bool `json:"KeepSession" yaml:"keepSession"` // Keep cookies and storage when resetting the display
+}
+
+const (
+	OverlayBottom = "bottom"
+	OverlayTop    = "top"
+)
+
+type OverlayConfig struct {
+	Position      string  `json:"Position" yaml:"position"`           // Edge the overlay bar is shown on: bottom (default) or top
+	Opacity       float64 `json:"Opacity" yaml:"opacity"`             // Opacity of the overlay bar (default 0.8)
+	Clock         bool    `json:"Clock" yaml:"clock"`                 // Show a clock
+	ClockFormat   string  `json:"ClockFormat" yaml:"clockFormat"`     // Clock format using YYYY, MM, DD, HH, hh, mm, ss and A (default HH:mm)
+	Ticker        string  `json:"Ticker" yaml:"ticker"`               // Scrolling announcement text
+	TickerURL     string  `json:"TickerURL" yaml:"tickerURL"`         // Plain text or RSS/Atom feed the ticker text is fetched from
+	TickerRefresh int     `json:"TickerRefresh" yaml:"tickerRefresh"` // Seconds between fetches of tickerURL (default 300)
+	TickerSpeed   int     `json:"TickerSpeed" yaml:"tickerSpeed"`     // Ticker speed in pixels per second (default 80)
+	Logo          string  `json:"Logo" yaml:"logo"`                   // Logo image URL or path
 }
 
 type DisplayConfig struct {
-	Name       string      `json:"Name" yaml:"name"`
-	DebugPort  int         `json:"DebugPort" yaml:"debugPort"`
-	X          int         `json:"X" yaml:"x"`
-	Y          int         `json:"Y" yaml:"y"`
-	Fullscreen bool        `json:"Fullscreen" yaml:"fullscreen"`
-	Exec       ExecConfig  `json:"Exec" yaml:"exec"`
-	Tabs       []TabConfig `json:"Tabs" yaml:"tabs"`
+	Name               string             `json:"Name" yaml:"name"`
+	DebugPort          int                `json:"DebugPort" yaml:"debugPort"`
+	XDisplay           string             `json:"XDisplay" yaml:"display"` // X display (DISPLAY) to run on, e.g. :0.1 (defaults to the kiosk's own DISPLAY)
+	X                  int                `json:"X" yaml:"x"`
+	Y                  int                `json:"Y" yaml:"y"`
+	Width              int                `json:"Width" yaml:"width"`       // Window width, overrides the top-level newWindowSize
+	Height             int                `json:"Height" yaml:"height"`     // Window height, overrides the top-level newWindowSize
+	Output             string             `json:"Output" yaml:"output"`     // xrandr output the window is placed on (e.g. HDMI-1)
+	Rotation           string             `json:"Rotation" yaml:"rotation"` // xrandr rotation applied to the output: normal, left, right or inverted
+	Fullscreen         bool               `json:"Fullscreen" yaml:"fullscreen"`
+	Transition         string             `json:"Transition" yaml:"transition"`                 // How tabs are switched: cut (default), fade or slide
+	TransitionDuration int                `json:"TransitionDuration" yaml:"transitionDuration"` // Milliseconds each half of a fade or slide takes (default 500)
+	Preload            bool               `json:"Preload" yaml:"preload"`                       // Refresh the next tab in the background while the current one is shown
+	Profile            ProfileConfig      `json:"Profile" yaml:"profile"`
+	Exec               ExecConfig         `json:"Exec" yaml:"exec"`
+	Layout             *LayoutConfig      `json:"Layout,omitempty" yaml:"layout,omitempty"`           // Tile several URLs on the display instead of cycling tabs
+	Overlay            *OverlayConfig     `json:"Overlay,omitempty" yaml:"overlay,omitempty"`         // Clock, ticker and logo shown on top of every tab
+	Interactive        *InteractiveConfig `json:"Interactive,omitempty" yaml:"interactive,omitempty"` // Pause rotation while the display is used and reset it when idle
+	Tabs               []TabConfig        `json:"Tabs" yaml:"tabs"`
+}
+
+var Rotations = []string{"normal", "left", "right", "inverted"}
+
+func ValidRotation(rotation string) bool {
+	for _, r := range Rotations {
+		if r == rotation {
+			return true
+		}
+	}
+	return false
 }
 
 type Config struct {
-	DwellTime     int             `json:"DwellTime" yaml:"dwellTime"`
-	DebugPort     int             `json:"DebugPort" yaml:"debugPort"`
-	NewWindowSize string          `json:"NewWindowSize" yaml:"newWindowSize"`
-	Displays      []DisplayConfig `json:"Displays" yaml:"displays"`
+	DwellTime             int             `json:"DwellTime" yaml:"dwellTime"`
+	DebugPort             int             `json:"DebugPort" yaml:"debugPort"`
+	NewWindowSize         string          `json:"NewWindowSize" yaml:"newWindowSize"`
+	GeometryCheckInterval int             `json:"GeometryCheckInterval" yaml:"geometryCheckInterval"` // Seconds between window position/size/fullscreen checks (0 = disabled)
+	Browser               BrowserConfig   `json:"Browser" yaml:"browser"`
+	SecretsFile           string          `json:"SecretsFile" yaml:"secretsFile"` // YAML or JSON file of named secrets used by login steps
+	Failure               FailureConfig   `json:"Failure" yaml:"failure"`
+	Displays              []DisplayConfig `json:"Displays" yaml:"displays"`
 }
 
 func Load(cfg *Config, filename string) error {
@@ -66,6 +355,29 @@ func Load(cfg *Config, filename string) error {
 	return nil
 }
 
+// LoadSecrets reads a flat map of secret names to values from a YAML or JSON
+// file.
+func LoadSecrets(filename string) (map[string]string, error) {
+	secrets := make(map[string]string)
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file %s: %w", filename, err)
+	}
+
+	fileExt := filepath.Ext(filename)
+	if fileExt == ".yaml" || fileExt == ".yml" {
+		if err := yaml.Unmarshal(data, &secrets); err != nil {
+			return nil, fmt.Errorf("failed to read secrets file %s: %w", filename, err)
+		}
+	} else {
+		if err := json.Unmarshal(data, &secrets); err != nil {
+			return nil, fmt.Errorf("failed to read secrets file %s: %w", filename, err)
+		}
+	}
+	return secrets, nil
+}
+
 func (c *Config) Save(filename string) error {
 	fileExt := filepath.Ext(filename)
 	var data []byte
@@ -129,3 +441,33 @@ func (c *Config) NextDisplayName() string {
 	}
 	return fmt.Sprintf("%s%d", strings.TrimSuffix(last, matches[0]), lastNum+1)
 }
+
+// WindowSize parses NewWindowSize ("width,height") into its components.
+func (c *Config) WindowSize() (int, int, bool) {
+	parts := strings.Split(c.NewWindowSize, ",")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+
+	width, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	height, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return width, height, true
+}
+
+// DisplayWindowSize returns the window size of a display, falling back to
+// NewWindowSize if the display doesn't set its own width and height.
+func (c *Config) DisplayWindowSize(d DisplayConfig) (int, int, bool) {
+	if d.Width > 0 && d.Height > 0 {
+		return d.Width, d.Height, true
+	}
+
+	return c.WindowSize()
+}
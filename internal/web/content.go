@@ -0,0 +1,209 @@
+package web
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"kiosk/internal/config"
+)
+
+const defaultSlideInterval = 10
+
+var (
+	imageExtensions = []string{".png", ".jpg", ".jpeg", ".gif", ".webp", ".svg", ".bmp"}
+	videoExtensions = []string{".mp4", ".webm", ".ogv", ".mov", ".m4v"}
+)
+
+type contentItem struct {
+	Kind string // image or video
+	Src  string
+}
+
+func mediaKind(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, e := range imageExtensions {
+		if ext == e {
+			return config.TabImage
+		}
+	}
+	for _, e := range videoExtensions {
+		if ext == e {
+			return config.TabVideo
+		}
+	}
+	return ""
+}
+
+// directoryMedia lists the images and videos in a directory in name order.
+func directoryMedia(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || mediaKind(entry.Name()) == "" {
+			continue
+		}
+		files = append(files, entry.Name())
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// findContentTab looks up a local content tab by display name and URL, the
+// same way the tab forms identify tabs. The caller must hold mu.
+func (kiosk *KioskWeb) findContentTab(displayName, tabURL string) *config.TabConfig {
+	idx := kiosk.cfg.IndexOfDisplay(displayName)
+	if idx == -1 {
+		return nil
+	}
+
+	for i, t := range kiosk.cfg.Displays[idx].Tabs {
+		if t.URL == tabURL && t.IsLocalContent() {
+			return &kiosk.cfg.Displays[idx].Tabs[i]
+		}
+	}
+
+	return nil
+}
+
+func contentFileURL(displayName, tabURL, name string) string {
+	v := url.Values{}
+	v.Set("display", displayName)
+	v.Set("url", tabURL)
+	if name != "" {
+		v.Set("name", name)
+	}
+	return "/content/file?" + v.Encode()
+}
+
+func readContent(tab *config.TabConfig) (string, error) {
+	if tab.Content != "" {
+		return tab.Content, nil
+	}
+
+	data, err := os.ReadFile(tab.URL)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// content renders the page shown by image, video, directory, markdown and
+// html tabs.
+func (kiosk *KioskWeb) content(w http.ResponseWriter, r *http.Request) {
+	displayName := r.URL.Query().Get("display")
+	tabURL := r.URL.Query().Get("url")
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	tab := kiosk.findContentTab(displayName, tabURL)
+	if tab == nil {
+		log.Printf("Content not found for display %s with URL %s", displayName, tabURL)
+		http.Error(w, "Content not found", http.StatusNotFound)
+		return
+	}
+
+	interval := tab.SlideInterval
+	if interval <= 0 {
+		interval = defaultSlideInterval
+	}
+
+	var items []contentItem
+	var slides []template.HTML
+
+	switch tab.Type {
+	case config.TabImage, config.TabVideo:
+		items = append(items, contentItem{Kind: tab.Type, Src: contentFileURL(displayName, tabURL, "")})
+	case config.TabDirectory:
+		files, err := directoryMedia(tab.URL)
+		if err != nil {
+			log.Printf("Error reading directory %s: %v", tab.URL, err)
+			http.Error(w, "Error reading directory", http.StatusNotFound)
+			return
+		}
+		for _, f := range files {
+			items = append(items, contentItem{Kind: mediaKind(f), Src: contentFileURL(displayName, tabURL, f)})
+		}
+	case config.TabMarkdown:
+		text, err := readContent(tab)
+		if err != nil {
+			log.Printf("Error reading markdown %s: %v", tab.URL, err)
+			http.Error(w, "Error reading markdown", http.StatusNotFound)
+			return
+		}
+		for _, slide := range splitSlides(text) {
+			slides = append(slides, template.HTML(renderMarkdown(slide)))
+		}
+	case config.TabHTML:
+		text, err := readContent(tab)
+		if err != nil {
+			log.Printf("Error reading HTML %s: %v", tab.URL, err)
+			http.Error(w, "Error reading HTML", http.StatusNotFound)
+			return
+		}
+		// The HTML comes from the kiosk's own config and is trusted
+		slides = append(slides, template.HTML(text))
+	default:
+		http.Error(w, "Unknown content type", http.StatusBadRequest)
+		return
+	}
+
+	err := templates.ExecuteTemplate(w, "content.html", struct {
+		Title         string
+		Items         []contentItem
+		Slides        []template.HTML
+		SlideInterval int
+	}{
+		Title:         filepath.Base(tab.URL),
+		Items:         items,
+		Slides:        slides,
+		SlideInterval: interval,
+	})
+	if err != nil {
+		log.Printf("Error rendering template: %v", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		return
+	}
+}
+
+// contentFile serves the media of an image, video or directory tab. Only the
+// tab's own file or the media files of its directory are served.
+func (kiosk *KioskWeb) contentFile(w http.ResponseWriter, r *http.Request) {
+	displayName := r.URL.Query().Get("display")
+	tabURL := r.URL.Query().Get("url")
+	name := r.URL.Query().Get("name")
+
+	mu.Lock()
+	tab := kiosk.findContentTab(displayName, tabURL)
+	var path string
+	if tab != nil {
+		switch tab.Type {
+		case config.TabImage, config.TabVideo:
+			path = tab.URL
+		case config.TabDirectory:
+			if name != "" && name == filepath.Base(name) && mediaKind(name) != "" {
+				path = filepath.Join(tab.URL, name)
+			}
+		}
+	}
+	mu.Unlock()
+
+	if path == "" {
+		log.Printf("Content file not found for display %s with URL %s: %s", displayName, tabURL, name)
+		http.Error(w, "Content not found", http.StatusNotFound)
+		return
+	}
+
+	http.ServeFile(w, r, path)
+}
@@ -11,6 +11,9 @@ import (
 
 type Example struct{}
 
+func (e *Example) Status() []web.DisplayStatus {
+	return nil
+}
 func (e *Example) AddDisplay(display config.DisplayConfig) error {
 	log.Printf("Adding display: %+v", display)
 	return nil
@@ -35,6 +38,10 @@ func (e *Example) EditTab(displayName string, tab config.TabConfig) error {
 	log.Printf("Editing tab on display %s: %+v", displayName, tab)
 	return nil
 }
+func (e *Example) SetTicker(displayName, text string) error {
+	log.Printf("Setting ticker on display %s: %s", displayName, text)
+	return nil
+}
 func (e *Example) ReloadDisplays() error {
 	log.Println("Reloading displays")
 	return nil
@@ -0,0 +1,101 @@
+package web
+
+import (
+	"log"
+	"math"
+	"net/http"
+
+	"kiosk/internal/config"
+)
+
+type layoutRegion struct {
+	Left, Top, Width, Height float64
+	URLs                     []string
+	DwellTime                int
+	RefreshInterval          int
+}
+
+// layoutRegions resolves the rectangles of a layout's regions. Regions with
+// an explicit width and height keep them, the others fill the grid in order.
+func layoutRegions(layout *config.LayoutConfig, defaultDwell int) []layoutRegion {
+	gridCount := 0
+	for _, r := range layout.Regions {
+		if r.Width <= 0 || r.Height <= 0 {
+			gridCount++
+		}
+	}
+
+	columns := layout.Columns
+	if columns <= 0 {
+		columns = int(math.Ceil(math.Sqrt(float64(gridCount))))
+	}
+	if columns <= 0 {
+		columns = 1
+	}
+
+	rows := layout.Rows
+	if rows <= 0 {
+		rows = int(math.Ceil(float64(gridCount) / float64(columns)))
+	}
+	if rows <= 0 {
+		rows = 1
+	}
+
+	regions := make([]layoutRegion, 0, len(layout.Regions))
+	cell := 0
+	for _, r := range layout.Regions {
+		region := layoutRegion{
+			Left:            r.X,
+			Top:             r.Y,
+			Width:           r.Width,
+			Height:          r.Height,
+			URLs:            r.URLs,
+			DwellTime:       r.DwellTime,
+			RefreshInterval: r.RefreshInterval,
+		}
+
+		if region.DwellTime <= 0 {
+			region.DwellTime = defaultDwell
+		}
+
+		if r.Width <= 0 || r.Height <= 0 {
+			region.Width = 100 / float64(columns)
+			region.Height = 100 / float64(rows)
+			region.Left = float64(cell%columns) * region.Width
+			region.Top = float64(cell/columns) * region.Height
+			cell++
+		}
+
+		regions = append(regions, region)
+	}
+
+	return regions
+}
+
+func (kiosk *KioskWeb) layout(w http.ResponseWriter, r *http.Request) {
+	displayName := r.URL.Query().Get("display")
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	idx := kiosk.cfg.IndexOfDisplay(displayName)
+	if idx == -1 || kiosk.cfg.Displays[idx].Layout == nil {
+		log.Printf("Layout not found: %s", displayName)
+		http.Error(w, "Layout not found", http.StatusNotFound)
+		return
+	}
+	regions := layoutRegions(kiosk.cfg.Displays[idx].Layout, kiosk.cfg.DwellTime)
+
+	err := templates.ExecuteTemplate(w, "layout.html", struct {
+		Display string
+		Regions []layoutRegion
+	}{
+		Display: displayName,
+		Regions: regions,
+	})
+	if err != nil {
+		log.Printf("Error rendering template: %v", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		return
+	}
+}
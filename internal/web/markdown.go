@@ -0,0 +1,184 @@
+package web
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// A small markdown renderer for slides: headings, paragraphs, lists, quotes,
+// fenced code, emphasis, inline code, links and images.
+
+var (
+	slideSeparator = regexp.MustCompile(`(?m)^\s*---+\s*$`)
+	headingLine    = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	unorderedItem  = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	orderedItem    = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+
+	inlineCode   = regexp.MustCompile("`([^`]+)`")
+	inlineImage  = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+	inlineLink   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	inlineStrong = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	inlineEm     = regexp.MustCompile(`\*([^*]+)\*`)
+)
+
+// splitSlides splits markdown into slides on lines consisting of "---".
+func splitSlides(text string) []string {
+	var slides []string
+	for _, slide := range slideSeparator.Split(text, -1) {
+		if strings.TrimSpace(slide) != "" {
+			slides = append(slides, slide)
+		}
+	}
+	return slides
+}
+
+// renderInline renders the inline markup of a line. Code spans are split off
+// first so their content is only escaped.
+func renderInline(text string) string {
+	var out strings.Builder
+	last := 0
+	for _, m := range inlineCode.FindAllStringSubmatchIndex(text, -1) {
+		out.WriteString(renderSpans(text[last:m[0]]))
+		out.WriteString("<code>" + html.EscapeString(text[m[2]:m[3]]) + "</code>")
+		last = m[1]
+	}
+	out.WriteString(renderSpans(text[last:]))
+
+	return out.String()
+}
+
+// renderSpans renders images, links and emphasis in text without code spans.
+// Links and images with unsafe URLs are reduced to their text.
+func renderSpans(text string) string {
+	text = html.EscapeString(text)
+
+	text = inlineImage.ReplaceAllStringFunc(text, func(m string) string {
+		parts := inlineImage.FindStringSubmatch(m)
+		if !safeURL(parts[2]) {
+			return parts[1]
+		}
+		return `<img alt="` + parts[1] + `" src="` + parts[2] + `" />`
+	})
+	text = inlineLink.ReplaceAllStringFunc(text, func(m string) string {
+		parts := inlineLink.FindStringSubmatch(m)
+		if !safeURL(parts[2]) {
+			return parts[1]
+		}
+		return `<a href="` + parts[2] + `">` + parts[1] + `</a>`
+	})
+	text = inlineStrong.ReplaceAllString(text, `<strong>$1</strong>`)
+	text = inlineEm.ReplaceAllString(text, `<em>$1</em>`)
+
+	return text
+}
+
+// safeURL reports whether an escaped link or image URL is relative or uses
+// http, https or mailto, keeping javascript: and data: URLs out of slides.
+func safeURL(escaped string) bool {
+	u, err := url.Parse(html.UnescapeString(escaped))
+	if err != nil {
+		return false
+	}
+
+	switch u.Scheme {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+func renderMarkdown(text string) string {
+	var out strings.Builder
+	var paragraph []string
+	list := ""
+	inCode := false
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + renderInline(strings.Join(paragraph, " ")) + "</p>\n")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if list != "" {
+			out.WriteString("</" + list + ">\n")
+			list = ""
+		}
+	}
+	openList := func(tag string) {
+		if list != tag {
+			closeList()
+			out.WriteString("<" + tag + ">\n")
+			list = tag
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			flushParagraph()
+			closeList()
+			if inCode {
+				out.WriteString("</code></pre>\n")
+			} else {
+				out.WriteString("<pre><code>")
+			}
+			inCode = !inCode
+			continue
+		}
+
+		if inCode {
+			out.WriteString(html.EscapeString(line) + "\n")
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			flushParagraph()
+			closeList()
+			continue
+		}
+
+		if m := headingLine.FindStringSubmatch(line); m != nil {
+			flushParagraph()
+			closeList()
+			tag := "h" + string(rune('0'+len(m[1])))
+			out.WriteString("<" + tag + ">" + renderInline(m[2]) + "</" + tag + ">\n")
+			continue
+		}
+
+		if m := unorderedItem.FindStringSubmatch(line); m != nil {
+			flushParagraph()
+			openList("ul")
+			out.WriteString("<li>" + renderInline(m[1]) + "</li>\n")
+			continue
+		}
+
+		if m := orderedItem.FindStringSubmatch(line); m != nil {
+			flushParagraph()
+			openList("ol")
+			out.WriteString("<li>" + renderInline(m[1]) + "</li>\n")
+			continue
+		}
+
+		if strings.HasPrefix(line, ">") {
+			flushParagraph()
+			closeList()
+			out.WriteString("<blockquote>" + renderInline(strings.TrimSpace(line[1:])) + "</blockquote>\n")
+			continue
+		}
+
+		closeList()
+		paragraph = append(paragraph, strings.TrimSpace(line))
+	}
+
+	flushParagraph()
+	closeList()
+	if inCode {
+		out.WriteString("</code></pre>\n")
+	}
+
+	return out.String()
+}
@@ -0,0 +1,89 @@
+package web
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSlides(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"single", "# One", []string{"# One"}},
+		{"separator", "# One\n---\n# Two", []string{"# One\n", "\n# Two"}},
+		{"long separator with spaces", "One\n  -----  \nTwo", []string{"One\n", "\nTwo"}},
+		{"empty slides dropped", "---\nOne\n---\n\n---\n", []string{"\nOne\n"}},
+		{"dashes inside a line", "a --- b", []string{"a --- b"}},
+		{"empty", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitSlides(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitSlides(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderInline(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "hello", "hello"},
+		{"escaped", `<b>"x" & y</b>`, "&lt;b&gt;&#34;x&#34; &amp; y&lt;/b&gt;"},
+		{"strong and em", "**bold** and *em*", "<strong>bold</strong> and <em>em</em>"},
+		{"code", "run `a < b`", "run <code>a &lt; b</code>"},
+		{"code keeps markup", "`**not bold** [x](y)`", "<code>**not bold** [x](y)</code>"},
+		{"many code spans", "`a` `b` `c` `d` `e` `f` `g` `h` `i` `j` `k`", "<code>a</code> <code>b</code> <code>c</code> <code>d</code> <code>e</code> <code>f</code> <code>g</code> <code>h</code> <code>i</code> <code>j</code> <code>k</code>"},
+		{"NUL in text", "a\x000\x00 `b`", "a\x000\x00 <code>b</code>"},
+		{"link", "[site](https://example.com/?a=1&b=2)", `<a href="https://example.com/?a=1&amp;b=2">site</a>`},
+		{"relative link", "[page](docs/page.html)", `<a href="docs/page.html">page</a>`},
+		{"mailto link", "[mail](mailto:kiosk@example.com)", `<a href="mailto:kiosk@example.com">mail</a>`},
+		{"image", "![logo](/static/logo.png)", `<img alt="logo" src="/static/logo.png" />`},
+		{"javascript link", "[click](javascript:alert(1)", "click"},
+		{"javascript link uppercase", "[click](JavaScript:alert)", "click"},
+		{"data image", "![x](data:image/svg+xml;base64,PHN2Zz4=)", "x"},
+		{"vbscript link", "[x](vbscript:msgbox)", "x"},
+		{"quote in url", `[x](https://example.com/"onmouseover=alert)`, `<a href="https://example.com/&#34;onmouseover=alert">x</a>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderInline(tt.text); got != tt.want {
+				t.Errorf("renderInline(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"heading", "## Title", "<h2>Title</h2>\n"},
+		{"paragraph joins lines", "one\ntwo\n\nthree", "<p>one two</p>\n<p>three</p>\n"},
+		{"unordered list", "- a\n* b", "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n"},
+		{"ordered list", "1. a\n2) b", "<ol>\n<li>a</li>\n<li>b</li>\n</ol>\n"},
+		{"list type change", "- a\n1. b", "<ul>\n<li>a</li>\n</ul>\n<ol>\n<li>b</li>\n</ol>\n"},
+		{"quote", "> *said*", "<blockquote><em>said</em></blockquote>\n"},
+		{"fenced code", "```\n<x> **y**\n```", "<pre><code>&lt;x&gt; **y**\n</code></pre>\n"},
+		{"unclosed fence", "```\ncode", "<pre><code>code\n</code></pre>\n"},
+		{"crlf", "# A\r\ntext\r\n", "<h1>A</h1>\n<p>text</p>\n"},
+		{"unsafe link in list", "- [x](javascript:alert)", "<ul>\n<li>x</li>\n</ul>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderMarkdown(tt.text); got != tt.want {
+				t.Errorf("renderMarkdown(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package web
 import (
 	"context"
 	"embed"
+	"fmt"
 	"html/template"
 	"io/fs"
 	"kiosk/internal/config"
@@ -20,13 +21,47 @@ var staticFS, _ = fs.Sub(_staticFS, "static")
 //go:embed templates
 var templateFS embed.FS
 
+type TabStatus struct {
+	URL             string
+	Active          bool
+	Failed          bool
+	Failures        int
+	LastError       string
+	HealthError     string
+	HealthFailures  int
+	LastHealthCheck time.Time
+	Preloading      bool
+	PreloadedAt     time.Time
+}
+
+type ExecStatus struct {
+	Command  string
+	PID      int
+	Running  bool
+	Restarts int
+	ExitCode int
+	LastExit time.Time
+	LastErr  string
+}
+
+type DisplayStatus struct {
+	Name                string
+	WindowID            string
+	GeometryCorrections int
+	Interacting         bool
+	Exec                *ExecStatus
+	Tabs                []TabStatus
+}
+
 type IKiosk interface {
+	Status() []DisplayStatus
 	AddDisplay(display config.DisplayConfig) error
 	RemoveDisplay(name string) error
 	AddTab(displayName string, tab config.TabConfig) error
 	RemoveTab(displayName, tabURL string) error
 	EditDisplay(display config.DisplayConfig) error
 	EditTab(displayName string, tab config.TabConfig) error
+	SetTicker(displayName, text string) error
 	ReloadDisplays() error
 }
 
@@ -41,6 +76,104 @@ func parseFormInt(r *http.Request, key string) int {
 	return val
 }
 
+func parseFormProfile(r *http.Request) config.ProfileConfig {
+	return config.ProfileConfig{
+		Policy:   r.FormValue("Profile.Policy"),
+		Dir:      r.FormValue("Profile.Dir"),
+		Template: r.FormValue("Profile.Template"),
+	}
+}
+
+func parseFormExec(r *http.Request) config.ExecConfig {
+	return config.ExecConfig{
+		Command:             r.FormValue("Exec.Command"),
+		Args:                r.Form["Exec.Args"],
+		WindowSearch:        r.FormValue("Exec.WindowSearch"),
+		DelayBeforeSendKeys: parseFormInt(r, "Exec.DelayBeforeSendKeys"),
+		SendKeys:            r.Form["Exec.SendKeys"],
+		Restart:             r.FormValue("Exec.Restart"),
+		RestartDelay:        parseFormInt(r, "Exec.RestartDelay"),
+		MaxRestartDelay:     parseFormInt(r, "Exec.MaxRestartDelay"),
+		Env:                 r.Form["Exec.Env"],
+		WorkingDir:          r.FormValue("Exec.WorkingDir"),
+		Shell:               r.FormValue("Exec.Shell") == "true",
+		User:                r.FormValue("Exec.User"),
+	}
+}
+
+func parseFormTab(r *http.Request) config.TabConfig {
+	return config.TabConfig{
+		URL:               r.FormValue("URL"),
+		Type:              r.FormValue("Type"),
+		Content:           r.FormValue("Content"),
+		SlideInterval:     parseFormInt(r, "SlideInterval"),
+		RefreshBeforeLoad: r.FormValue("RefreshBeforeLoad") == "true",
+		RefreshAfterLoad:  r.FormValue("RefreshAfterLoad") == "true",
+		RefreshInterval:   parseFormInt(r, "RefreshInterval"),
+		RefreshMode:       r.FormValue("RefreshMode"),
+		RefreshScript:     r.FormValue("RefreshScript"),
+		RefreshTimer:      r.FormValue("RefreshTimer") == "true",
+		DelayAfterRefresh: parseFormInt(r, "DelayAfterRefresh"),
+		DwellTime:         parseFormInt(r, "DwellTime"),
+		WaitFor:           r.FormValue("WaitFor"),
+		WaitForSelector:   r.FormValue("WaitForSelector"),
+		LoadTimeout:       parseFormInt(r, "LoadTimeout"),
+		Zoom:              parseFormFloat(r, "Zoom"),
+		InjectCSS:         r.FormValue("InjectCSS"),
+		InjectJS:          r.FormValue("InjectJS"),
+	}
+}
+
+// parseFormInteractive enables interactive mode when an idle timeout is given,
+// keeping the other settings of an existing config.
+func parseFormInteractive(r *http.Request, existing *config.InteractiveConfig) *config.InteractiveConfig {
+	timeout := parseFormInt(r, "IdleTimeout")
+	if timeout <= 0 {
+		return nil
+	}
+
+	interactive := &config.InteractiveConfig{}
+	if existing != nil {
+		*interactive = *existing
+	}
+	interactive.IdleTimeout = timeout
+
+	return interactive
+}
+
+func interactiveIdleTimeout(interactive *config.InteractiveConfig) int {
+	if interactive == nil {
+		return 0
+	}
+
+	if interactive.IdleTimeout <= 0 {
+		return config.DefaultIdleTimeout
+	}
+
+	return interactive.IdleTimeout
+}
+
+// waitForOptions lists the readiness events offered by the tab form, including
+// any other lifecycle event the tab is configured with so editing keeps it.
+func waitForOptions(current string) []string {
+	for _, event := range config.WaitForEvents {
+		if event == current {
+			return config.WaitForEvents
+		}
+	}
+
+	if current == "" {
+		return config.WaitForEvents
+	}
+
+	return append(append([]string{}, config.WaitForEvents...), current)
+}
+
+func parseFormFloat(r *http.Request, key string) float64 {
+	val, _ := strconv.ParseFloat(r.FormValue(key), 64)
+	return val
+}
+
 // Routes
 func (kiosk *KioskWeb) index(w http.ResponseWriter, r *http.Request) {
 	http.ServeFileFS(w, r, staticFS, "index.html")
@@ -64,6 +197,22 @@ func (kiosk *KioskWeb) getDisplayList(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (kiosk *KioskWeb) getStatus(w http.ResponseWriter, r *http.Request) {
+	var status []DisplayStatus
+	if kiosk.options.Parent != nil {
+		status = kiosk.options.Parent.Status()
+	}
+
+	err := templates.ExecuteTemplate(w, "status.html", struct {
+		Displays []DisplayStatus
+	}{Displays: status})
+	if err != nil {
+		log.Printf("Error rendering template: %v", err)
+		http.Error(w, "Error rendering template", http.StatusInternalServerError)
+		return
+	}
+}
+
 func (kiosk *KioskWeb) displayReloadForm(w http.ResponseWriter, r *http.Request) {
 	err := templates.ExecuteTemplate(w, "display_reload.html", struct {
 	}{})
@@ -76,19 +225,36 @@ func (kiosk *KioskWeb) displayReloadForm(w http.ResponseWriter, r *http.Request)
 
 func (kiosk *KioskWeb) displayAddForm(w http.ResponseWriter, r *http.Request) {
 	err := templates.ExecuteTemplate(w, "display_form.html", struct {
-		Edit       bool
-		DebugPort  int
-		Name       string
-		X, Y       int
-		Fullscreen bool
-		Exec       config.ExecConfig
+		Edit          bool
+		DebugPort     int
+		Name          string
+		XDisplay      string
+		X, Y          int
+		Width, Height int
+		Output        string
+		Rotation      string
+		Rotations     []string
+		Fullscreen    bool
+		Transition    string
+		Duration      int
+		Preload       bool
+		IdleTimeout   int
+		Transitions   []string
+		Profile       config.ProfileConfig
+		Policies      []string
+		Restarts      []string
+		Exec          config.ExecConfig
 	}{
-		DebugPort:  kiosk.cfg.NextDebugPort(),
-		Name:       kiosk.cfg.NextDisplayName(),
-		X:          0,
-		Y:          0,
-		Fullscreen: false,
-		Edit:       false,
+		DebugPort:   kiosk.cfg.NextDebugPort(),
+		Name:        kiosk.cfg.NextDisplayName(),
+		X:           0,
+		Y:           0,
+		Rotations:   config.Rotations,
+		Fullscreen:  false,
+		Transitions: config.Transitions,
+		Policies:    config.ProfilePolicies,
+		Restarts:    config.RestartPolicies,
+		Edit:        false,
 		Exec: config.ExecConfig{
 			Command:      "",
 			Args:         []string{},
@@ -116,20 +282,47 @@ func (kiosk *KioskWeb) displayEditForm(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err := templates.ExecuteTemplate(w, "display_form.html", struct {
-		Edit       bool
-		DebugPort  int
-		Name       string
-		X, Y       int
-		Fullscreen bool
-		Exec       config.ExecConfig
+		Edit          bool
+		DebugPort     int
+		Name          string
+		XDisplay      string
+		X, Y          int
+		Width, Height int
+		Output        string
+		Rotation      string
+		Rotations     []string
+		Fullscreen    bool
+		Transition    string
+		Duration      int
+		Preload       bool
+		IdleTimeout   int
+		Transitions   []string
+		Profile       config.ProfileConfig
+		Policies      []string
+		Restarts      []string
+		Exec          config.ExecConfig
 	}{
-		DebugPort:  kiosk.cfg.Displays[idx].DebugPort,
-		Name:       kiosk.cfg.Displays[idx].Name,
-		X:          kiosk.cfg.Displays[idx].X,
-		Y:          kiosk.cfg.Displays[idx].Y,
-		Fullscreen: kiosk.cfg.Displays[idx].Fullscreen,
-		Edit:       true,
-		Exec:       kiosk.cfg.Displays[idx].Exec,
+		DebugPort:   kiosk.cfg.Displays[idx].DebugPort,
+		Name:        kiosk.cfg.Displays[idx].Name,
+		XDisplay:    kiosk.cfg.Displays[idx].XDisplay,
+		X:           kiosk.cfg.Displays[idx].X,
+		Y:           kiosk.cfg.Displays[idx].Y,
+		Width:       kiosk.cfg.Displays[idx].Width,
+		Height:      kiosk.cfg.Displays[idx].Height,
+		Output:      kiosk.cfg.Displays[idx].Output,
+		Rotation:    kiosk.cfg.Displays[idx].Rotation,
+		Rotations:   config.Rotations,
+		Fullscreen:  kiosk.cfg.Displays[idx].Fullscreen,
+		Transition:  kiosk.cfg.Displays[idx].Transition,
+		Preload:     kiosk.cfg.Displays[idx].Preload,
+		IdleTimeout: interactiveIdleTimeout(kiosk.cfg.Displays[idx].Interactive),
+		Duration:    kiosk.cfg.Displays[idx].TransitionDuration,
+		Transitions: config.Transitions,
+		Profile:     kiosk.cfg.Displays[idx].Profile,
+		Policies:    config.ProfilePolicies,
+		Restarts:    config.RestartPolicies,
+		Edit:        true,
+		Exec:        kiosk.cfg.Displays[idx].Exec,
 	})
 	if err != nil {
 		log.Printf("Error rendering template: %v", err)
@@ -151,12 +344,23 @@ func (kiosk *KioskWeb) displayAdd(w http.ResponseWriter, r *http.Request) {
 	}
 
 	newDisplay := config.DisplayConfig{
-		Name:       name,
-		DebugPort:  parseFormInt(r, "DebugPort"),
-		X:          parseFormInt(r, "X"),
-		Y:          parseFormInt(r, "Y"),
-		Fullscreen: r.FormValue("Fullscreen") == "true",
-		Tabs:       []config.TabConfig{},
+		Name:               name,
+		DebugPort:          parseFormInt(r, "DebugPort"),
+		XDisplay:           r.FormValue("XDisplay"),
+		X:                  parseFormInt(r, "X"),
+		Y:                  parseFormInt(r, "Y"),
+		Width:              parseFormInt(r, "Width"),
+		Height:             parseFormInt(r, "Height"),
+		Output:             r.FormValue("Output"),
+		Rotation:           r.FormValue("Rotation"),
+		Fullscreen:         r.FormValue("Fullscreen") == "true",
+		Transition:         r.FormValue("Transition"),
+		TransitionDuration: parseFormInt(r, "TransitionDuration"),
+		Preload:            r.FormValue("Preload") == "true",
+		Interactive:        parseFormInteractive(r, nil),
+		Profile:            parseFormProfile(r),
+		Exec:               parseFormExec(r),
+		Tabs:               []config.TabConfig{},
 	}
 
 	if kiosk.options.Parent != nil {
@@ -191,16 +395,23 @@ func (kiosk *KioskWeb) displayEdit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	kiosk.cfg.Displays[idx].DebugPort = parseFormInt(r, "DebugPort")
+	kiosk.cfg.Displays[idx].XDisplay = r.FormValue("XDisplay")
 	kiosk.cfg.Displays[idx].X = parseFormInt(r, "X")
 	kiosk.cfg.Displays[idx].Y = parseFormInt(r, "Y")
+	kiosk.cfg.Displays[idx].Width = parseFormInt(r, "Width")
+	kiosk.cfg.Displays[idx].Height = parseFormInt(r, "Height")
+	kiosk.cfg.Displays[idx].Output = r.FormValue("Output")
+	kiosk.cfg.Displays[idx].Rotation = r.FormValue("Rotation")
 	kiosk.cfg.Displays[idx].Fullscreen = r.FormValue("Fullscreen") == "true"
-	kiosk.cfg.Displays[idx].Exec = config.ExecConfig{
-		Command:             r.FormValue("Exec.Command"),
-		Args:                r.Form["Exec.Args"],
-		WindowSearch:        r.FormValue("Exec.WindowSearch"),
-		DelayBeforeSendKeys: parseFormInt(r, "Exec.DelayBeforeSendKeys"),
-		SendKeys:            r.Form["Exec.SendKeys"],
-	}
+	kiosk.cfg.Displays[idx].Transition = r.FormValue("Transition")
+	kiosk.cfg.Displays[idx].TransitionDuration = parseFormInt(r, "TransitionDuration")
+	kiosk.cfg.Displays[idx].Preload = r.FormValue("Preload") == "true"
+	kiosk.cfg.Displays[idx].Interactive = parseFormInteractive(r, kiosk.cfg.Displays[idx].Interactive)
+	kiosk.cfg.Displays[idx].Profile = parseFormProfile(r)
+	// Action sequences can only be edited in the config file
+	actions := kiosk.cfg.Displays[idx].Exec.Actions
+	kiosk.cfg.Displays[idx].Exec = parseFormExec(r)
+	kiosk.cfg.Displays[idx].Exec.Actions = actions
 
 	if kiosk.options.Parent != nil {
 		if err := kiosk.options.Parent.EditDisplay(kiosk.cfg.Displays[idx]); err != nil {
@@ -262,6 +473,24 @@ func (kiosk *KioskWeb) displayRemove(w http.ResponseWriter, r *http.Request) {
 	kiosk.getDisplayList(w, r)
 }
 
+// displayTicker replaces the overlay ticker text of a running display. The
+// change is not saved to the config file.
+func (kiosk *KioskWeb) displayTicker(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	name := r.FormValue("Display")
+	text := r.FormValue("Ticker")
+
+	if kiosk.options.Parent != nil {
+		if err := kiosk.options.Parent.SetTicker(name, text); err != nil {
+			log.Printf("Error setting ticker: %v", err)
+			http.Error(w, "Error setting ticker", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	fmt.Fprint(w, "Updated")
+}
+
 func (kiosk *KioskWeb) tabAdd(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 	name := r.FormValue("Display")
@@ -269,14 +498,7 @@ func (kiosk *KioskWeb) tabAdd(w http.ResponseWriter, r *http.Request) {
 
 	for i, d := range kiosk.cfg.Displays {
 		if d.Name == name {
-			kiosk.cfg.Displays[i].Tabs = append(kiosk.cfg.Displays[i].Tabs, config.TabConfig{
-				URL:               r.FormValue("URL"),
-				RefreshBeforeLoad: r.FormValue("RefreshBeforeLoad") == "true",
-				RefreshAfterLoad:  r.FormValue("RefreshAfterLoad") == "true",
-				RefreshInterval:   parseFormInt(r, "RefreshInterval"),
-				DelayAfterRefresh: parseFormInt(r, "DelayAfterRefresh"),
-				DwellTime:         parseFormInt(r, "DwellTime"),
-			})
+			kiosk.cfg.Displays[i].Tabs = append(kiosk.cfg.Displays[i].Tabs, parseFormTab(r))
 			break
 		}
 	}
@@ -295,20 +517,30 @@ func (kiosk *KioskWeb) tabForm(w http.ResponseWriter, r *http.Request) {
 	displayName := r.URL.Query().Get("display")
 
 	err := templates.ExecuteTemplate(w, "tab_form.html", struct {
-		Display string
-		Tab     *config.TabConfig
-		Edit    bool
+		Display       string
+		Tab           *config.TabConfig
+		Edit          bool
+		WaitForEvents []string
+		TabTypes      []string
+		RefreshModes  []string
 	}{
 		Display: displayName,
 		Tab: &config.TabConfig{
 			URL:               "",
+			Type:              config.TabURL,
 			RefreshBeforeLoad: false,
 			RefreshAfterLoad:  false,
 			RefreshInterval:   30,
-			DelayAfterRefresh: 3,
+			DelayAfterRefresh: 0,
 			DwellTime:         kiosk.cfg.DwellTime,
+			RefreshMode:       config.RefreshNavigate,
+			WaitFor:           config.WaitForLoad,
+			LoadTimeout:       30,
 		},
-		Edit: true,
+		Edit:          true,
+		WaitForEvents: config.WaitForEvents,
+		TabTypes:      config.TabTypes,
+		RefreshModes:  config.RefreshModes,
 	})
 	if err != nil {
 		log.Printf("Error rendering template: %v", err)
@@ -346,13 +578,19 @@ func (kiosk *KioskWeb) tabEditForm(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err := templates.ExecuteTemplate(w, "tab_form.html", struct {
-		Display string
-		Tab     *config.TabConfig
-		Edit    bool
+		Display       string
+		Tab           *config.TabConfig
+		Edit          bool
+		WaitForEvents []string
+		TabTypes      []string
+		RefreshModes  []string
 	}{
-		Display: displayName,
-		Tab:     tab,
-		Edit:    true,
+		Display:       displayName,
+		Tab:           tab,
+		Edit:          true,
+		WaitForEvents: waitForOptions(tab.WaitFor),
+		TabTypes:      config.TabTypes,
+		RefreshModes:  config.RefreshModes,
 	})
 	if err != nil {
 		log.Printf("Error rendering template: %v", err)
@@ -366,14 +604,7 @@ func (kiosk *KioskWeb) tabEdit(w http.ResponseWriter, r *http.Request) {
 	displayName := r.FormValue("Display")
 	originalURL := r.FormValue("OriginalURL")
 
-	newTab := config.TabConfig{
-		URL:               r.FormValue("URL"),
-		RefreshBeforeLoad: r.FormValue("RefreshBeforeLoad") == "true",
-		RefreshAfterLoad:  r.FormValue("RefreshAfterLoad") == "true",
-		RefreshInterval:   parseFormInt(r, "RefreshInterval"),
-		DelayAfterRefresh: parseFormInt(r, "DelayAfterRefresh"),
-		DwellTime:         parseFormInt(r, "DwellTime"),
-	}
+	newTab := parseFormTab(r)
 
 	mu.Lock()
 
@@ -389,6 +620,18 @@ func (kiosk *KioskWeb) tabEdit(w http.ResponseWriter, r *http.Request) {
 	found := false
 	for i, t := range kiosk.cfg.Displays[idx].Tabs {
 		if t.URL == originalURL {
+			// Keep settings that can only be edited in the config file
+			newTab.Login = t.Login
+			newTab.Headers = t.Headers
+			newTab.Cookies = t.Cookies
+			newTab.BasicAuth = t.BasicAuth
+			newTab.HealthCheck = t.HealthCheck
+			newTab.ScrollTo = t.ScrollTo
+			newTab.Emulation = t.Emulation
+			newTab.AutoScroll = t.AutoScroll
+			newTab.NavigationLock = t.NavigationLock
+			newTab.Actions = t.Actions
+
 			if kiosk.options.Parent != nil {
 				if err := kiosk.options.Parent.EditTab(displayName, newTab); err != nil {
 					log.Printf("Error editing tab: %v", err)
@@ -555,6 +798,10 @@ func (kiosk *KioskWeb) Start() {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/", kiosk.index)
+	mux.HandleFunc("/status", kiosk.getStatus)
+	mux.HandleFunc("/layout", kiosk.layout)
+	mux.HandleFunc("/content", kiosk.content)
+	mux.HandleFunc("/content/file", kiosk.contentFile)
 	mux.HandleFunc("/display/list", kiosk.getDisplayList)
 	mux.HandleFunc("/display/reload", kiosk.displayReloadConfirmed)
 	mux.HandleFunc("/display/reload-form", kiosk.displayReloadForm)
@@ -564,6 +811,7 @@ func (kiosk *KioskWeb) Start() {
 	mux.HandleFunc("/display/edit", kiosk.displayEdit)
 	mux.HandleFunc("/display/remove-form", kiosk.displayRemoveForm)
 	mux.HandleFunc("/display/remove", kiosk.displayRemove)
+	mux.HandleFunc("/display/ticker", kiosk.displayTicker)
 	mux.HandleFunc("/tab/new-form", kiosk.tabForm)
 	mux.HandleFunc("/tab/add", kiosk.tabAdd)
 	mux.HandleFunc("/tab/remove-form", kiosk.tabRemoveForm)